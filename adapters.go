@@ -18,8 +18,10 @@ type Predicate[M any] func(measure M) bool
 type IterFunc[V any] func(value V) bool
 
 // FingerTree is a parameterized wrapper on a low-level finger tree.
+// A reversed tree holds its values in the opposite order from f, see [Reverse].
 type FingerTree[MS Measurer[Value, Measure], Value, Measure any] struct {
-	f fingerTree
+	f        fingerTree[Value, Measure]
+	reversed bool
 }
 
 type Measurer[Value, Measure any] interface {
@@ -33,31 +35,16 @@ type Measurer[Value, Measure any] interface {
 	Sum(a Measure, b Measure) Measure
 }
 
-func wrapTree[MS Measurer[V, M], V, M any](tree fingerTree) FingerTree[MS, V, M] {
-	return FingerTree[MS, V, M]{tree}
+func wrapTree[MS Measurer[V, M], V, M any](tree fingerTree[V, M]) FingerTree[MS, V, M] {
+	return FingerTree[MS, V, M]{f: tree}
 }
 
-var ErrBadValue = fmt.Errorf("%w, bad value", ErrFingerTree)
-
-func wrapPredicate[M any](pred Predicate[M]) func(any) bool {
-	return func(m any) bool {
-		if wm, ok := m.(M); !ok {
-			panic(fmt.Errorf("%w, predicate input: %v", ErrBadValue, m))
-		} else {
-			return pred(wm)
-		}
-	}
+// wrap a tree with the same direction as t
+func (t FingerTree[MS, V, M]) wrap(tree fingerTree[V, M]) FingerTree[MS, V, M] {
+	return FingerTree[MS, V, M]{tree, t.reversed}
 }
 
-func wrapIter[V any](iter IterFunc[V]) func(any) bool {
-	return func(v any) bool {
-		if wv, ok := v.(V); !ok {
-			panic(fmt.Errorf("%w, iteration value: %v", ErrBadValue, v))
-		} else {
-			return iter(wv)
-		}
-	}
-}
+var ErrBadValue = fmt.Errorf("%w, bad value", ErrFingerTree)
 
 func null[T any]() T {
 	return *new(T)
@@ -65,66 +52,162 @@ func null[T any]() T {
 
 // Add a value to the start of the tree.
 func (t FingerTree[MS, V, M]) AddFirst(value V) FingerTree[MS, V, M] {
-	return wrapTree[MS, V, M](t.f.AddFirst(value))
+	if t.reversed {
+		return t.wrap(t.f.AddLast(leaf[V, M](value)))
+	}
+	return wrapTree[MS, V, M](t.f.AddFirst(leaf[V, M](value)))
 }
 
 // Add a value to the and of the tree.
 func (t FingerTree[MS, V, M]) AddLast(value V) FingerTree[MS, V, M] {
-	return wrapTree[MS, V, M](t.f.AddLast(value))
+	if t.reversed {
+		return t.wrap(t.f.AddFirst(leaf[V, M](value)))
+	}
+	return wrapTree[MS, V, M](t.f.AddLast(leaf[V, M](value)))
 }
 
 // Remove the first value in the tree. Make sure to test whether the tree is empty
 // because this will panic if it is.
 func (t FingerTree[MS, V, M]) RemoveFirst() FingerTree[MS, V, M] {
+	if t.reversed {
+		return t.wrap(t.f.RemoveLast())
+	}
 	return wrapTree[MS, V, M](t.f.RemoveFirst())
 }
 
 // Remove the last value in the tree. Make sure to test whether the tree is empty
 // because this will panic if it is.
 func (t FingerTree[MS, V, M]) RemoveLast() FingerTree[MS, V, M] {
+	if t.reversed {
+		return t.wrap(t.f.RemoveFirst())
+	}
 	return wrapTree[MS, V, M](t.f.RemoveLast())
 }
 
 // Return the first value in the tree. Make sure to test whether the tree is empty
 // because this will panic if it is.
 func (t FingerTree[MS, V, M]) PeekFirst() V {
-	if cv, ok := t.f.PeekFirst().(V); !ok {
-		panic(fmt.Errorf("%w, first value in tree: %v", ErrBadValue, t.f.PeekFirst()))
-	} else {
-		return cv
+	if t.reversed {
+		return t.f.PeekLast().value
 	}
+	return t.f.PeekFirst().value
 }
 
 // Return the last value in the tree. Make sure to test whether the tree is empty
 // because this will panic if it is.
 func (t FingerTree[MS, V, M]) PeekLast() V {
-	if cv, ok := t.f.PeekLast().(V); !ok {
-		panic(fmt.Errorf("%w, last value in tree: %v", ErrBadValue, t.f.PeekLast()))
-	} else {
-		return cv
+	if t.reversed {
+		return t.f.PeekFirst().value
 	}
+	return t.f.PeekLast().value
 }
 
-// Join two finger trees together
+// Join two finger trees together. Joining a reversed tree to one that is not
+// reversed mirrors other lazily, see [FingerTree.Reverse].
 func (t FingerTree[MS, V, M]) Concat(other FingerTree[MS, V, M]) FingerTree[MS, V, M] {
-	return wrapTree[MS, V, M](t.f.Concat(other.f))
+	if t.reversed {
+		return t.wrap(other.direction(true).Concat(t.f))
+	}
+	return wrapTree[MS, V, M](t.f.Concat(other.direction(false)))
+}
+
+// Add all of the values to the end of the tree. The values are built into a
+// tree in linear time and then concatenated, so this is much faster than
+// calling AddLast for each one.
+func (t FingerTree[MS, V, M]) AppendSlice(values []V) FingerTree[MS, V, M] {
+	if t.reversed {
+		return t.wrap(fromSeq(measurerFor(t.f), backward(values)).Concat(t.f))
+	}
+	return wrapTree[MS, V, M](t.f.Concat(fromArray(measurerFor(t.f), values)))
+}
+
+// Add all of the values to the start of the tree, keeping their order.
+// Like [AppendSlice], this builds the values in linear time and then concatenates.
+func (t FingerTree[MS, V, M]) PrependSlice(values []V) FingerTree[MS, V, M] {
+	if t.reversed {
+		return t.wrap(t.f.Concat(fromSeq(measurerFor(t.f), backward(values))))
+	}
+	return wrapTree[MS, V, M](fromArray(measurerFor(t.f), values).Concat(t.f))
+}
+
+// Return whether the predicate holds for the measure of the whole tree. This
+// only measures the part of the tree up to where it first holds.
+func (t FingerTree[MS, V, M]) holds(pred Predicate[M]) bool {
+	if t.f == nil || isEmpty(t.f) {
+		return false
+	}
+	meas := measurerFor(t.f)
+	if t.reversed {
+		_, ok := holdsRight(meas, t.f, pred, meas.Identity())
+		return ok
+	}
+	_, ok := holds(meas, t.f, pred, meas.Identity())
+	return ok
 }
 
 // Split the tree. The first tree is all the starting values that do not satisfy the predicate.
 // The second tree is the first value that satisfies the predicate, followed by the rest of the values.
 func (t FingerTree[MS, V, M]) Split(predicate Predicate[M]) (FingerTree[MS, V, M], FingerTree[MS, V, M]) {
-	left, right := t.f.Split(wrapPredicate(predicate))
+	if t.reversed {
+		if !t.holds(predicate) {
+			return t, t.wrap(newEmptyTree(measurerFor(t.f)))
+		}
+		// the reversed tree's prefix is the suffix of f
+		rest, mid, suffix := t.f.splitTreeRight(predicate, measurerFor(t.f).Identity())
+		return t.wrap(suffix), t.wrap(rest.AddLast(mid))
+	}
+	left, right := t.f.Split(predicate)
 	return wrapTree[MS, V, M](left), wrapTree[MS, V, M](right)
 }
 
+// Split the tree around the first value where the predicate becomes true,
+// returning the values before it, the value, the values after it, and the
+// measure of the values before it. This is like calling [Split] and then
+// PeekFirst and RemoveFirst on the second tree but it does not add the value
+// back to the second tree. If the predicate never becomes true, this returns
+// the whole tree as left, an empty right tree, and false.
+func (t FingerTree[MS, V, M]) SplitAround(pred Predicate[M]) (left FingerTree[MS, V, M], value V, right FingerTree[MS, V, M], prefix M, ok bool) {
+	if !t.holds(pred) {
+		return t, value, t.wrap(newEmptyTree(measurerFor(t.f))), t.Measure(), false
+	}
+	var l, r fingerTree[V, M]
+	var mid elem[V, M]
+	if t.reversed {
+		r, mid, l = t.f.splitTreeRight(pred, measurerFor(t.f).Identity())
+	} else {
+		l, mid, r = t.f.splitTree(pred, measurerFor(t.f).Identity())
+	}
+	return t.wrap(l), mid.value, t.wrap(r), l.measurement(), true
+}
+
+// Find the first value where the predicate becomes true for the measure of all
+// the values up to and including it. Returns the measure of the values before it,
+// the value, and whether the predicate was satisfied at all. This is like
+// calling [Split] and then PeekFirst on the second tree but it does not build
+// any trees.
+func (t FingerTree[MS, V, M]) Lookup(pred Predicate[M]) (prefix M, value V, ok bool) {
+	if !t.holds(pred) {
+		return prefix, value, false
+	}
+	if t.reversed {
+		prefix, item := t.f.lookupRight(pred, measurerFor(t.f).Identity())
+		return prefix, item.value, true
+	}
+	prefix, item := t.f.lookup(pred, measurerFor(t.f).Identity())
+	return prefix, item.value, true
+}
+
 // Return a slice containing all of the values in the tree
 func (t FingerTree[MS, V, M]) ToSlice() []V {
-	s := t.f.ToSlice()
-	result := make([]V, len(s))
-	for i := 0; i < len(s); i++ {
-		result[i] = s[i].(V)
+	if t.reversed {
+		result := make([]V, 0, 8)
+		t.f.EachReverse(func(v V) bool {
+			result = append(result, v)
+			return true
+		})
+		return result
 	}
-	return result
+	return t.f.ToSlice()
 }
 
 func (t FingerTree[MS, V, M]) IsZero() bool {
@@ -132,6 +215,9 @@ func (t FingerTree[MS, V, M]) IsZero() bool {
 }
 
 func (t FingerTree[MS, V, M]) String() string {
+	if t.reversed {
+		return fmt.Sprintf("reversed{%s}", t.f)
+	}
 	return t.f.String()
 }
 
@@ -146,26 +232,37 @@ func (t FingerTree[MS, V, M]) IsEmpty() bool {
 
 // Return the measure of all the tree's values
 func (t FingerTree[MS, V, M]) Measure() M {
-	if cm, ok := t.f.measurement().value.(M); !ok {
-		panic(fmt.Errorf("%w, measurement in tree: %v", ErrBadValue, t.f.measurement().value))
-	} else {
-		return cm
-	}
+	return t.f.measurement()
 }
 
 // Return all the initial values in the tree that do not satisfy the predicate
 func (t FingerTree[MS, V, M]) TakeUntil(pred Predicate[M]) FingerTree[MS, V, M] {
-	return wrapTree[MS, V, M](takeUntil(t.f, wrapPredicate(pred)))
+	if t.reversed {
+		left, _ := t.Split(pred)
+		return left
+	}
+	return wrapTree[MS, V, M](takeUntil(t.f, pred))
 }
 
 // Discard all the initial values in the tree that do not satisfy the predicate
 func (t FingerTree[MS, V, M]) DropUntil(pred Predicate[M]) FingerTree[MS, V, M] {
-	return wrapTree[MS, V, M](dropUntil(t.f, wrapPredicate(pred)))
+	if t.reversed {
+		_, right := t.Split(pred)
+		return right
+	}
+	return wrapTree[MS, V, M](dropUntil(t.f, pred))
 }
 
 // Iterate through the tree starting at the beginning
 func (t FingerTree[MS, V, M]) Each(iter IterFunc[V]) {
-	t.f.Each(wrapIter(iter))
+	t.each(iter)
+}
+
+func (t FingerTree[MS, V, M]) each(iter IterFunc[V]) bool {
+	if t.reversed {
+		return t.f.EachReverse(iter)
+	}
+	return t.f.Each(iter)
 }
 
 func (t FingerTree[MS, V, M]) Seq() iter.Seq[V] {
@@ -176,7 +273,14 @@ func (t FingerTree[MS, V, M]) Seq() iter.Seq[V] {
 
 // Iterate through the tree starting at the end
 func (t FingerTree[MS, V, M]) EachReverse(iter IterFunc[V]) {
-	t.f.EachReverse(wrapIter(iter))
+	t.eachReverse(iter)
+}
+
+func (t FingerTree[MS, V, M]) eachReverse(iter IterFunc[V]) bool {
+	if t.reversed {
+		return t.f.Each(iter)
+	}
+	return t.f.EachReverse(iter)
 }
 
 func (t FingerTree[MS, V, M]) SeqReverse() iter.Seq[V] {
@@ -194,49 +298,43 @@ func AsMeasurer[V, M any](m any) Measurer[V, M] {
 	}
 }
 
-type adaptedMeasurer[MS Measurer[V, M], V, M any] struct {
-	am MS
-}
-
-func (m adaptedMeasurer[MS, V, M]) Identity() any {
-	return m.am.Identity()
-}
-
-func (m adaptedMeasurer[MS, V, M]) Measure(value any) any {
-	if v, ok := value.(V); !ok {
-		panic(fmt.Errorf("%w, measure: %v", ErrBadValue, value))
-	} else {
-		return m.am.Measure(v)
-	}
-}
-
-func (m adaptedMeasurer[MS, V, M]) Sum(a any, b any) any {
-	if va, ok := a.(M); !ok {
-		panic(fmt.Errorf("%w, sum: %v", ErrBadValue, a))
-	} else if vb, ok2 := b.(M); !ok2 {
-		panic(fmt.Errorf("%w, sum: %v", ErrBadValue, b))
-	} else {
-		return m.am.Sum(va, vb)
-	}
-}
-
 // Create a finger tree. You shouldn't need to provide the type parameters,
 // Go should be able to infer them from your arguments.
 // So you should just be able to say,
 //
 //	t := FromArray(myMeasurer, []Plant{plant1, plant2})
+//
+// This takes linear time, it does not add the values one at a time.
 func FromArray[MS Measurer[V, M], V, M any](measurer MS, values []V) FingerTree[MS, V, M] {
-	cvt := make([]any, len(values))
-	for i := 0; i < len(values); i++ {
-		cvt[i] = values[i]
-	}
-	return wrapTree[MS, V, M](fromArray(adaptedMeasurer[MS, V, M]{measurer}, cvt))
+	return wrapTree[MS, V, M](fromArray[V, M](measurer, values))
+}
+
+// Create a finger tree from a sequence of values in linear time.
+// The sequence is consumed as it is read, it is not collected into a slice first.
+func FromSeq[MS Measurer[V, M], V, M any](measurer MS, values iter.Seq[V]) FingerTree[MS, V, M] {
+	return wrapTree[MS, V, M](fromSeq[V, M](measurer, values))
 }
 
 func Concat[MS Measurer[V, M], V, M any](trees ...FingerTree[MS, V, M]) FingerTree[MS, V, M] {
-	result := newEmptyTree(trees[0].f.measurement().measurer)
+	result := wrapTree[MS, V, M](newEmptyTree(measurerFor(trees[0].f)))
 	for _, t := range trees {
-		result = result.Concat(t.f)
+		result = result.Concat(t)
+	}
+	return result
+}
+
+// Join finger trees together, returning an error instead of panicking when
+// there are no trees or their measurers are incompatible.
+func TryConcat[MS Measurer[V, M], V, M any](trees ...FingerTree[MS, V, M]) (FingerTree[MS, V, M], error) {
+	if len(trees) == 0 {
+		return FingerTree[MS, V, M]{}, fmt.Errorf("%w: cannot call Concat with no trees", ErrEmptyTree)
+	}
+	result := trees[0]
+	for _, t := range trees[1:] {
+		var err error
+		if result, err = result.TryConcat(t); err != nil {
+			return FingerTree[MS, V, M]{}, err
+		}
 	}
-	return wrapTree[MS, V, M](result)
+	return result, nil
 }
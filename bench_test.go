@@ -0,0 +1,156 @@
+package lazyfingertree
+
+import (
+	"testing"
+)
+
+const benchSize = 100_000
+
+func benchTree() FingerTree[width[int, int], int, int] {
+	nums := make([]int, benchSize)
+	for i := range nums {
+		nums[i] = i
+	}
+	return newTree(nums...)
+}
+
+func BenchmarkFromArray(b *testing.B) {
+	nums := make([]int, benchSize)
+	for i := range nums {
+		nums[i] = i
+	}
+	b.ReportAllocs()
+	for b.Loop() {
+		newTree(nums...)
+	}
+}
+
+func BenchmarkAddLast(b *testing.B) {
+	b.ReportAllocs()
+	for b.Loop() {
+		tree := newTree[int]()
+		for i := range 1000 {
+			tree = tree.AddLast(i)
+		}
+	}
+}
+
+func BenchmarkRemoveFirst(b *testing.B) {
+	tree := benchTree()
+	b.ReportAllocs()
+	for b.Loop() {
+		t := tree
+		for range 1000 {
+			t = t.RemoveFirst()
+		}
+	}
+}
+
+func BenchmarkSplit(b *testing.B) {
+	tree := benchTree()
+	i := 0
+	b.ReportAllocs()
+	for b.Loop() {
+		pos := (i * 7919) % benchSize
+		tree.Split(func(w int) bool { return w > pos })
+		i++
+	}
+}
+
+func BenchmarkSplitAround(b *testing.B) {
+	tree := benchTree()
+	i := 0
+	b.ReportAllocs()
+	for b.Loop() {
+		pos := (i * 7919) % benchSize
+		tree.SplitAround(func(w int) bool { return w > pos })
+		i++
+	}
+}
+
+func BenchmarkLookup(b *testing.B) {
+	tree := benchTree()
+	i := 0
+	b.ReportAllocs()
+	for b.Loop() {
+		pos := (i * 7919) % benchSize
+		tree.Lookup(func(w int) bool { return w > pos })
+		i++
+	}
+}
+
+func BenchmarkConcat(b *testing.B) {
+	tree := benchTree()
+	left, right := tree.Split(func(w int) bool { return w > benchSize/3 })
+	b.ReportAllocs()
+	for b.Loop() {
+		left.Concat(right).Measure()
+	}
+}
+
+func BenchmarkEach(b *testing.B) {
+	tree := benchTree()
+	b.ReportAllocs()
+	for b.Loop() {
+		sum := 0
+		tree.Each(func(v int) bool {
+			sum += v
+			return true
+		})
+	}
+}
+
+func BenchmarkBuilderAddLast(b *testing.B) {
+	b.ReportAllocs()
+	for b.Loop() {
+		builder := newTree[int]().Transient()
+		for i := range 1000 {
+			builder.AddLast(i)
+		}
+		builder.Persistent()
+	}
+}
+
+func BenchmarkSeqRange(b *testing.B) {
+	tree := benchTree()
+	b.ReportAllocs()
+	for b.Loop() {
+		for range tree.SeqRange(func(m int) bool { return m > 50000 }, func(m int) bool { return m > 50100 }) {
+		}
+	}
+}
+
+func BenchmarkSplitRange(b *testing.B) {
+	tree := benchTree()
+	b.ReportAllocs()
+	for b.Loop() {
+		middle := tree.TakeUntil(func(m int) bool { return m > 50100 }).DropUntil(func(m int) bool { return m > 50000 })
+		for range middle.Seq() {
+		}
+	}
+}
+
+// type 1000 characters in the middle of a tree
+func BenchmarkBuilderInsert(b *testing.B) {
+	tree := benchTree()
+	b.ReportAllocs()
+	for b.Loop() {
+		builder := tree.Transient()
+		for i := range 1000 {
+			builder.Insert(func(w int) bool { return w > benchSize/2+i }, i)
+		}
+		builder.Persistent()
+	}
+}
+
+func BenchmarkInsert(b *testing.B) {
+	tree := benchTree()
+	b.ReportAllocs()
+	for b.Loop() {
+		t := tree
+		for i := range 1000 {
+			left, right := t.Split(func(w int) bool { return w > benchSize/2+i })
+			t = left.AddLast(i).Concat(right)
+		}
+	}
+}
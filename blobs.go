@@ -0,0 +1,228 @@
+package lazyfingertree
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+var ErrNotFound = fmt.Errorf("%w, blob not found", ErrFingerTree)
+
+// A Hash identifies a blob by the SHA-256 hash of its contents
+type Hash [sha256.Size]byte
+
+func hashBlob(data []byte) Hash {
+	return sha256.Sum256(data)
+}
+
+func (h Hash) String() string {
+	return hex.EncodeToString(h[:])
+}
+
+// Parse a hash from its hex string
+func ParseHash(s string) (Hash, error) {
+	var h Hash
+	b, err := hex.DecodeString(s)
+	if err != nil || len(b) != len(h) {
+		return h, fmt.Errorf("%w, bad hash: %q", ErrBadValue, s)
+	}
+	copy(h[:], b)
+	return h, nil
+}
+
+// A BlobStore holds content-addressed blobs
+type BlobStore interface {
+	Has(h Hash) (bool, error)
+	// Return the blob or ErrNotFound
+	Get(h Hash) ([]byte, error)
+	Put(h Hash, data []byte) error
+	Delete(h Hash) error
+	Hashes() ([]Hash, error)
+}
+
+// A DirStore keeps each blob in a file named by its hash, in subdirectories
+// named by the first two hex digits of the hash.
+type DirStore struct {
+	dir string
+}
+
+func NewDirStore(dir string) (*DirStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &DirStore{dir}, nil
+}
+
+func (s *DirStore) path(h Hash) string {
+	name := h.String()
+	return filepath.Join(s.dir, name[:2], name[2:])
+}
+
+func (s *DirStore) Has(h Hash) (bool, error) {
+	_, err := os.Stat(s.path(h))
+	if errors.Is(err, fs.ErrNotExist) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func (s *DirStore) Get(h Hash) ([]byte, error) {
+	data, err := os.ReadFile(s.path(h))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, h)
+	}
+	return data, err
+}
+
+// Write the blob to a temporary file and rename it so readers never see a
+// partial blob
+func (s *DirStore) Put(h Hash, data []byte) error {
+	path := s.path(h)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".tmp-")
+	if err != nil {
+		return err
+	}
+	_, err = tmp.Write(data)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), path)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+	}
+	return err
+}
+
+func (s *DirStore) Delete(h Hash) error {
+	err := os.Remove(s.path(h))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+func (s *DirStore) Hashes() ([]Hash, error) {
+	var result []Hash
+	err := filepath.WalkDir(s.dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || strings.HasPrefix(d.Name(), ".") {
+			return err
+		}
+		if h, err := ParseHash(filepath.Base(filepath.Dir(path)) + d.Name()); err == nil {
+			result = append(result, h)
+		}
+		return nil
+	})
+	return result, err
+}
+
+// A PackStore appends blobs to a pack file and keeps them in memory. Each
+// record in the pack is a hash, a uvarint length, and the blob. Deleting a
+// blob only forgets it, use WriteTo to write a compacted pack.
+type PackStore struct {
+	w     io.Writer
+	blobs map[Hash][]byte
+}
+
+// Create an empty pack store that appends to w
+func NewPackStore(w io.Writer) *PackStore {
+	return &PackStore{w, map[Hash][]byte{}}
+}
+
+// Read the records of a pack and return a store that appends new blobs to w.
+// W may be nil for a read-only store.
+func ReadPack(r io.Reader, w io.Writer) (*PackStore, error) {
+	s := NewPackStore(w)
+	br := bufio.NewReader(r)
+	for {
+		var h Hash
+		if _, err := io.ReadFull(br, h[:]); err == io.EOF {
+			return s, nil
+		} else if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrBadEncoding, err)
+		}
+		size, err := binary.ReadUvarint(br)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrBadEncoding, err)
+		}
+		data := make([]byte, size)
+		if _, err := io.ReadFull(br, data); err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrBadEncoding, err)
+		}
+		s.blobs[h] = data
+	}
+}
+
+func writeRecord(w io.Writer, h Hash, data []byte) (int64, error) {
+	record := make([]byte, 0, len(h)+binary.MaxVarintLen64+len(data))
+	record = append(record, h[:]...)
+	record = binary.AppendUvarint(record, uint64(len(data)))
+	record = append(record, data...)
+	n, err := w.Write(record)
+	return int64(n), err
+}
+
+func (s *PackStore) Has(h Hash) (bool, error) {
+	_, ok := s.blobs[h]
+	return ok, nil
+}
+
+func (s *PackStore) Get(h Hash) ([]byte, error) {
+	if data, ok := s.blobs[h]; ok {
+		return data, nil
+	}
+	return nil, fmt.Errorf("%w: %s", ErrNotFound, h)
+}
+
+func (s *PackStore) Put(h Hash, data []byte) error {
+	if s.w == nil {
+		return fmt.Errorf("%w: read-only pack", ErrUnsupported)
+	} else if _, ok := s.blobs[h]; ok {
+		return nil
+	} else if _, err := writeRecord(s.w, h, data); err != nil {
+		return err
+	}
+	s.blobs[h] = data
+	return nil
+}
+
+func (s *PackStore) Delete(h Hash) error {
+	delete(s.blobs, h)
+	return nil
+}
+
+func (s *PackStore) Hashes() ([]Hash, error) {
+	result := make([]Hash, 0, len(s.blobs))
+	for h := range s.blobs {
+		result = append(result, h)
+	}
+	slices.SortFunc(result, func(a, b Hash) int { return strings.Compare(string(a[:]), string(b[:])) })
+	return result, nil
+}
+
+// Write a compacted pack containing only the blobs the store still holds
+func (s *PackStore) WriteTo(w io.Writer) (int64, error) {
+	hashes, _ := s.Hashes()
+	total := int64(0)
+	for _, h := range hashes {
+		n, err := writeRecord(w, h, s.blobs[h])
+		total += n
+		if err != nil {
+			return total, err
+		}
+	}
+	return total, nil
+}
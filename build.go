@@ -0,0 +1,52 @@
+package lazyfingertree
+
+// A treeBuilder constructs a finger tree from a stream of items in linear time.
+// The first three items become the left digit. Later items collect in the right
+// digit and when it overflows, its first three items are packed into a node
+// which is streamed into the builder for the mid tree.
+// A builder must not be used after build() is called.
+type treeBuilder[V, M any] struct {
+	measurer Measurer[V, M]
+	left     []elem[V, M]
+	right    []elem[V, M]
+	mid      *treeBuilder[V, M]
+}
+
+func newTreeBuilder[V, M any](measurer Measurer[V, M]) *treeBuilder[V, M] {
+	return &treeBuilder[V, M]{measurer: measurer}
+}
+
+func (b *treeBuilder[V, M]) add(item elem[V, M]) {
+	if len(b.left) < 3 {
+		b.left = append(b.left, item)
+		return
+	}
+	b.right = append(b.right, item)
+	if len(b.right) == 4 {
+		if b.mid == nil {
+			b.mid = newTreeBuilder(b.measurer)
+		}
+		b.mid.add(newNode(b.measurer, b.right[:3]...).asElem())
+		b.right[0] = b.right[3]
+		b.right = b.right[:1]
+	}
+}
+
+func (b *treeBuilder[V, M]) build() fingerTree[V, M] {
+	meas := b.measurer
+	if len(b.left) == 0 {
+		return newEmptyTree(meas)
+	} else if len(b.right) == 0 {
+		if len(b.left) == 1 {
+			return newSingleTree(meas, b.left[0])
+		}
+		return newDeepTree(meas, newDigit(meas, b.left[:1]...), newEmptyTree(meas), newDigit(meas, b.left[1:]...))
+	}
+	var mid fingerTree[V, M]
+	if b.mid == nil {
+		mid = newEmptyTree(meas)
+	} else {
+		mid = b.mid.build()
+	}
+	return newDeepTree(meas, newDigit(meas, b.left...), mid, newDigit(meas, b.right...))
+}
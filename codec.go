@@ -0,0 +1,180 @@
+package lazyfingertree
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"fmt"
+	"io"
+	"iter"
+	"reflect"
+)
+
+// Trees are serialized as their values, measures are recomputed when they are
+// decoded. Decoding streams the values into a tree in linear time.
+//
+// Unmarshaling into a tree uses the tree's measurer if it has one. Otherwise
+// it uses the zero value of the measurer type, which works for measurers like
+// struct{} types that have no fields. When the measurer type is an interface
+// there is no zero measurer to use and decoding returns ErrBadValue.
+//
+// A zero tree encodes as JSON null and an empty tree as [], and each decodes
+// back to the same kind of tree.
+
+var ErrBadEncoding = fmt.Errorf("%w, bad encoding", ErrFingerTree)
+
+func (t FingerTree[MS, V, M]) measurerOrZero() Measurer[V, M] {
+	if t.f != nil {
+		return measurerFor(t.f)
+	}
+	return null[MS]()
+}
+
+// Return the measurer to decode into t with
+func (t FingerTree[MS, V, M]) decodeMeasurer() (MS, error) {
+	if meas, ok := t.measurerOrZero().(MS); ok {
+		return meas, nil
+	}
+	return null[MS](), fmt.Errorf("%w: cannot decode into a zero tree with interface measurer type %v", ErrBadValue, reflect.TypeFor[MS]())
+}
+
+// Encode the tree as a JSON array of its values, or null if it is a zero tree
+func (t FingerTree[MS, V, M]) MarshalJSON() ([]byte, error) {
+	if t.f == nil {
+		return []byte("null"), nil
+	}
+	var buf bytes.Buffer
+	var err error
+	buf.WriteByte('[')
+	first := true
+	t.each(func(v V) bool {
+		if !first {
+			buf.WriteByte(',')
+		}
+		first = false
+		var data []byte
+		if data, err = json.Marshal(v); err == nil {
+			buf.Write(data)
+		}
+		return err == nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	buf.WriteByte(']')
+	return buf.Bytes(), nil
+}
+
+// Decode a JSON array of values into the tree. Like other decoders, null
+// leaves the tree unchanged, so it stays zero if it was.
+func (t *FingerTree[MS, V, M]) UnmarshalJSON(data []byte) error {
+	if string(bytes.TrimSpace(data)) == "null" {
+		return nil
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if tok, err := dec.Token(); err != nil {
+		return err
+	} else if tok != json.Delim('[') {
+		return fmt.Errorf("%w: expected a JSON array", ErrBadEncoding)
+	}
+	meas, err := t.decodeMeasurer()
+	if err != nil {
+		return err
+	}
+	tree := fromSeq[V, M](meas, func(yield func(V) bool) {
+		for dec.More() {
+			var v V
+			if err = dec.Decode(&v); err != nil || !yield(v) {
+				return
+			}
+		}
+	})
+	if err != nil {
+		return err
+	} else if _, err = dec.Token(); err != nil {
+		return err
+	}
+	*t = wrapTree[MS, V, M](tree)
+	return nil
+}
+
+// Encode the tree with gob, in the same format as [Encode]
+func (t FingerTree[MS, V, M]) GobEncode() ([]byte, error) {
+	var buf bytes.Buffer
+	if err := t.Encode(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Decode a tree encoded with [GobEncode]
+func (t *FingerTree[MS, V, M]) GobDecode(data []byte) error {
+	meas, err := t.decodeMeasurer()
+	if err != nil {
+		return err
+	}
+	tree, err := Decode(bytes.NewReader(data), meas)
+	if err != nil {
+		return err
+	}
+	*t = tree
+	return nil
+}
+
+// Write the tree to w as a gob stream: the number of values followed by the
+// values. The values are streamed, the tree is not copied into a slice first,
+// so this walks the tree twice.
+func (t FingerTree[MS, V, M]) Encode(w io.Writer) error {
+	enc := gob.NewEncoder(w)
+	count := 0
+	if t.f != nil {
+		t.each(func(V) bool {
+			count++
+			return true
+		})
+	}
+	if err := enc.Encode(count); err != nil {
+		return err
+	}
+	var err error
+	if t.f != nil {
+		t.each(func(v V) bool {
+			err = enc.Encode(&v)
+			return err == nil
+		})
+	}
+	return err
+}
+
+// Read a tree written by [Encode], measuring the values with measurer
+func Decode[MS Measurer[V, M], V, M any](r io.Reader, measurer MS) (FingerTree[MS, V, M], error) {
+	return DecodeN(r, measurer, -1)
+}
+
+// Read at most n values of a tree written by [Encode], or all of them if n is
+// negative. The rest of the values are not read.
+func DecodeN[MS Measurer[V, M], V, M any](r io.Reader, measurer MS, n int) (FingerTree[MS, V, M], error) {
+	dec := gob.NewDecoder(r)
+	var count int
+	if err := dec.Decode(&count); err != nil {
+		return FingerTree[MS, V, M]{}, err
+	} else if count < 0 {
+		return FingerTree[MS, V, M]{}, fmt.Errorf("%w: negative count %d", ErrBadEncoding, count)
+	}
+	if n < 0 || n > count {
+		n = count
+	}
+	var err error
+	tree := FromSeq(measurer, iter.Seq[V](func(yield func(V) bool) {
+		for i := 0; i < n; i++ {
+			var v V
+			if err = dec.Decode(&v); err != nil || !yield(v) {
+				return
+			}
+		}
+	}))
+	if err != nil {
+		return FingerTree[MS, V, M]{}, err
+	}
+	return tree, nil
+}
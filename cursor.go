@@ -0,0 +1,148 @@
+package lazyfingertree
+
+// A Cursor is a position in a tree for editing near a focus value. It holds
+// the values before the focus and the values after it as separate trees, so
+// moving one step or editing at the focus only touches the finger ends of
+// those trees, which is amortized O(1). Call Tree to join the parts back
+// together.
+//
+// Cursors are persistent like trees, the methods return new cursors. A cursor
+// for an empty tree has no focus until a value is inserted.
+type Cursor[MS Measurer[V, M], V, M any] struct {
+	measurer Measurer[V, M]
+	left     FingerTree[MS, V, M]
+	right    FingerTree[MS, V, M]
+	focus    V
+	hasFocus bool
+	// the measure of left
+	prefix M
+}
+
+// Return a cursor focused on the first value of the tree.
+func (t FingerTree[MS, V, M]) Cursor() Cursor[MS, V, M] {
+	meas := t.measurerOrZero()
+	c := Cursor[MS, V, M]{
+		measurer: meas,
+		left:     t.wrap(newEmptyTree(meas)),
+		right:    t.wrap(newEmptyTree(meas)),
+		prefix:   meas.Identity(),
+	}
+	if t.f != nil && !isEmpty(t.f) {
+		c.focus = t.PeekFirst()
+		c.hasFocus = true
+		c.right = t.RemoveFirst()
+	}
+	return c
+}
+
+// Return the focus value and whether there is one
+func (c Cursor[MS, V, M]) Focus() (V, bool) {
+	return c.focus, c.hasFocus
+}
+
+// Return the measure of the values before the focus
+func (c Cursor[MS, V, M]) Prefix() M {
+	return c.prefix
+}
+
+// Return the values before the focus
+func (c Cursor[MS, V, M]) Left() FingerTree[MS, V, M] {
+	return c.left
+}
+
+// Return the values after the focus
+func (c Cursor[MS, V, M]) Right() FingerTree[MS, V, M] {
+	return c.right
+}
+
+// Move the focus to the next value. If there is none, return the cursor
+// unchanged and false.
+func (c Cursor[MS, V, M]) Next() (Cursor[MS, V, M], bool) {
+	if !c.hasFocus || c.right.IsEmpty() {
+		return c, false
+	}
+	c.left = c.left.AddLast(c.focus)
+	c.prefix = c.measurer.Sum(c.prefix, c.measurer.Measure(c.focus))
+	c.focus = c.right.PeekFirst()
+	c.right = c.right.RemoveFirst()
+	return c, true
+}
+
+// Move the focus to the previous value. If there is none, return the cursor
+// unchanged and false.
+func (c Cursor[MS, V, M]) Prev() (Cursor[MS, V, M], bool) {
+	if !c.hasFocus || c.left.IsEmpty() {
+		return c, false
+	}
+	c.right = c.right.AddFirst(c.focus)
+	c.focus = c.left.PeekLast()
+	c.left = c.left.RemoveLast()
+	c.prefix = c.left.Measure()
+	return c, true
+}
+
+// Move the focus to the first value where the predicate holds, like
+// [FingerTree.Lookup]. This rebuilds the tree so it is O(log n). If the
+// predicate never holds, return the cursor unchanged and false.
+func (c Cursor[MS, V, M]) Seek(predicate Predicate[M]) (Cursor[MS, V, M], bool) {
+	left, focus, right, prefix, ok := c.Tree().SplitAround(predicate)
+	if !ok {
+		return c, false
+	}
+	c.left, c.focus, c.right, c.prefix = left, focus, right, prefix
+	c.hasFocus = true
+	return c, true
+}
+
+// Insert a value before the focus, the focus does not change. If there was
+// no focus, the value becomes the focus.
+func (c Cursor[MS, V, M]) InsertBefore(value V) Cursor[MS, V, M] {
+	if !c.hasFocus {
+		return c.Replace(value)
+	}
+	c.left = c.left.AddLast(value)
+	c.prefix = c.measurer.Sum(c.prefix, c.measurer.Measure(value))
+	return c
+}
+
+// Insert a value after the focus, the focus does not change. If there was no
+// focus, the value becomes the focus.
+func (c Cursor[MS, V, M]) InsertAfter(value V) Cursor[MS, V, M] {
+	if !c.hasFocus {
+		return c.Replace(value)
+	}
+	c.right = c.right.AddFirst(value)
+	return c
+}
+
+// Remove the focus value. The next value becomes the focus or, if there is
+// none, the previous value.
+func (c Cursor[MS, V, M]) Delete() Cursor[MS, V, M] {
+	if !c.right.IsEmpty() {
+		c.focus = c.right.PeekFirst()
+		c.right = c.right.RemoveFirst()
+	} else if !c.left.IsEmpty() {
+		c.focus = c.left.PeekLast()
+		c.left = c.left.RemoveLast()
+		c.prefix = c.left.Measure()
+	} else {
+		c.focus = null[V]()
+		c.hasFocus = false
+	}
+	return c
+}
+
+// Replace the focus value
+func (c Cursor[MS, V, M]) Replace(value V) Cursor[MS, V, M] {
+	c.focus = value
+	c.hasFocus = true
+	return c
+}
+
+// Return the tree with the cursor's edits
+func (c Cursor[MS, V, M]) Tree() FingerTree[MS, V, M] {
+	if !c.hasFocus {
+		return c.left.Concat(c.right)
+	}
+	return c.left.AddLast(c.focus).Concat(c.right)
+}
@@ -0,0 +1,79 @@
+package lazyfingertree
+
+import (
+	"testing"
+)
+
+func TestCursor(t *testing.T) {
+	c := newTree[int]().Cursor()
+	_, ok := c.Focus()
+	failIfNot(t, !ok && c.Tree().IsEmpty())
+	c = c.InsertAfter(1).InsertAfter(3).InsertBefore(0)
+	failIfNot(t, same(c.Tree().ToSlice(), []int{0, 1, 3}))
+	c, ok = c.Next()
+	failIfNot(t, ok)
+	c = c.InsertBefore(2)
+	v, _ := c.Focus()
+	failIfNot(t, v == 3 && c.Prefix() == 3)
+	_, ok = c.Next()
+	failIfNot(t, !ok)
+	failIfNot(t, same(c.Tree().ToSlice(), []int{0, 1, 2, 3}))
+	c = c.Delete()
+	v, _ = c.Focus()
+	failIfNot(t, v == 2 && c.Prefix() == 2)
+	for range 3 {
+		c = c.Delete()
+	}
+	_, ok = c.Focus()
+	failIfNot(t, !ok && c.Tree().IsEmpty())
+}
+
+func TestCursorEditing(t *testing.T) {
+	tree := lazyTree(10000)
+	c, ok := tree.Cursor().Seek(func(m int) bool { return m > 5000 })
+	failIfNot(t, ok && c.Prefix() == 5000)
+	_, ok = c.Seek(func(m int) bool { return m > 10000 })
+	failIfNot(t, !ok)
+	expected := tree.ToSlice()
+	// type a few characters and move around like an editor
+	for i := range 100 {
+		c = c.InsertBefore(-i)
+		expected = append(expected[:5000+i:5000+i], append([]int{-i}, expected[5000+i:]...)...)
+	}
+	c = c.Replace(-1000)
+	expected[5100] = -1000
+	for range 50 {
+		c, _ = c.Prev()
+	}
+	failIfNot(t, c.Prefix() == 5050)
+	v, _ := c.Focus()
+	failIfNot(t, v == expected[5050])
+	c = c.Delete()
+	expected = append(expected[:5050:5050], expected[5051:]...)
+	failIfNot(t, c.Left().Measure()+c.Right().Measure()+1 == len(expected))
+	failIfNot(t, same(c.Tree().ToSlice(), expected))
+	failIfNot(t, tree.Measure() == 10000)
+}
+
+func TestCursorReversed(t *testing.T) {
+	calls := 0
+	values := make([]int, 100000)
+	for i := range values {
+		values[i] = i
+	}
+	tree := FromArray(countingWidth{&calls}, values).Reverse()
+	calls = 0
+	c := tree.Cursor()
+	v, _ := c.Focus()
+	failIfNot(t, v == 99999 && c.Tree().IsReversed())
+	c, ok := c.Seek(func(m int) bool { return m > 100 })
+	v, _ = c.Focus()
+	failIfNot(t, ok && v == 99999-100 && c.Prefix() == 100)
+	c, _ = c.Next()
+	v, _ = c.Focus()
+	failIfNot(t, v == 99999-101)
+	if calls > 1000 {
+		t.Fatalf("cursor on a reversed tree measured %d values", calls)
+	}
+	failIfNot(t, same(c.Tree().ToSlice(), reversed(values)))
+}
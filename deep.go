@@ -6,174 +6,168 @@ import (
 )
 
 // A finger-tree which contains more than one element.
-type deepTree struct {
-	measured     bool
-	_measurement measurement
-	left         *digit
-	mid          fingerTree
-	right        *digit
+// The measurement is computed lazily because the mid tree is often delayed.
+type deepTree[V, M any] struct {
+	measurer     Measurer[V, M]
+	_measurement lazy[M]
+	left         *digit[V, M]
+	mid          fingerTree[V, M]
+	right        *digit[V, M]
 }
 
-func newDeepTree(measurer measurer, left *digit, mid fingerTree, right *digit) *deepTree {
-	return &deepTree{
-		false,
-		measurement{measurer, measurer.Identity()},
-		left,
-		mid,
-		right,
+func newDeepTree[V, M any](measurer Measurer[V, M], left *digit[V, M], mid fingerTree[V, M], right *digit[V, M]) *deepTree[V, M] {
+	return &deepTree[V, M]{
+		measurer: measurer,
+		left:     left,
+		mid:      mid,
+		right:    right,
 	}
 }
 
-func (d *deepTree) String() string {
+func (d *deepTree[V, M]) String() string {
 	return fmt.Sprintf("deepTree{%s, %s, %s}", d.left, d.mid, d.right)
 }
 
-func (d *deepTree) Dump(w io.Writer, level int) {
-	fmt.Fprintf(w, "%*sMeasurement: %s\n", level, "", d.measurement().value)
-	fmt.Fprintf(w, "%*sLeft: %s\n", level, "", d.left._measurement.value)
+func (d *deepTree[V, M]) Dump(w io.Writer, level int) {
+	fmt.Fprintf(w, "%*sMeasurement: %v\n", level, "", d.measurement())
+	fmt.Fprintf(w, "%*sLeft: %v\n", level, "", d.left.getMeasurement())
 	d.dumpDigits(w, level, d.left)
 	suffix := "\n"
-	mid := d.mid
-	if del, ok := d.mid.(*delayed); ok {
-		mid = del.force()
-	}
-	if _, ok := mid.(*singleTree); ok {
+	mid := force(d.mid)
+	if _, ok := mid.(*singleTree[V, M]); ok {
 		suffix = " "
 	}
 	fmt.Fprintf(w, "%*sMid:%s", level, "", suffix)
 	mid.Dump(w, level+2)
-	fmt.Fprintf(w, "%*sRight: %s\n", level, "", d.right._measurement.value)
+	fmt.Fprintf(w, "%*sRight: %v\n", level, "", d.right.getMeasurement())
 	d.dumpDigits(w, level, d.right)
 }
 
-func (d *deepTree) dumpDigits(w io.Writer, level int, dig *digit) {
-	for _, v := range dig.items {
-		fmt.Fprintf(w, "%*s%s %s\n", level+2, "", d.left._measurement.measurer.Measure(v), Brief(v))
+func (d *deepTree[V, M]) dumpDigits(w io.Writer, level int, dig *digit[V, M]) {
+	for _, v := range dig.elems() {
+		fmt.Fprintf(w, "%*s%v %s\n", level+2, "", v.measure(d.measurer), Brief(v.item()))
 	}
 }
 
-func (d *deepTree) measurement() measurement {
-	if !d.measured {
-		meas := d._measurement.measurer
-		d._measurement.value = meas.Sum(
-			meas.Sum(d.left._measurement.value, d.mid.measurement().value),
-			d.right._measurement.value,
-		)
-		d.measured = true
-	}
-	return d._measurement
+func (d *deepTree[V, M]) measurement() M {
+	return d._measurement.get(d.computeMeasurement)
 }
 
-func (d *deepTree) AddFirst(v any) fingerTree {
-	var meas = measurerFor(d)
-	leftItems := d.left.items
+func (d *deepTree[V, M]) computeMeasurement() M {
+	meas := d.measurer
+	return meas.Sum(
+		meas.Sum(d.left.getMeasurement(), d.mid.measurement()),
+		d.right.getMeasurement(),
+	)
+}
+
+func (d *deepTree[V, M]) getMeasurer() Measurer[V, M] {
+	return d.measurer
+}
+
+func (d *deepTree[V, M]) AddFirst(v elem[V, M]) fingerTree[V, M] {
+	meas := d.measurer
+	leftItems := d.left.elems()
 	if len(leftItems) == 4 {
 		return newDeepTree(
 			meas,
-			newDigit(meas, []any{v, leftItems[0]}),
-			d.mid.AddFirst(newNode(meas, []any{leftItems[1], leftItems[2], leftItems[3]})),
+			newDigit(meas, v, leftItems[0]),
+			d.mid.AddFirst(newNode(meas, leftItems[1], leftItems[2], leftItems[3]).asElem()),
 			d.right,
 		)
 	}
-	digits := make([]any, len(leftItems)+1)
-	digits[0] = v
-	copy(digits[1:], leftItems)
 	return newDeepTree(
 		meas,
-		newDigit(meas, digits),
+		d.left.addFirst(meas, v),
 		d.mid,
 		d.right,
 	)
 }
 
-func (d *deepTree) AddLast(v any) fingerTree {
-	meas := measurerFor(d)
-	rightItems := d.right.items
+func (d *deepTree[V, M]) AddLast(v elem[V, M]) fingerTree[V, M] {
+	meas := d.measurer
+	rightItems := d.right.elems()
 	if d.right.len() == 4 {
 		return newDeepTree(
 			meas,
 			d.left,
-			d.mid.AddLast(newNode(meas, []any{rightItems[0], rightItems[1], rightItems[2]})),
-			newDigit(meas, []any{rightItems[3], v}),
+			d.mid.AddLast(newNode(meas, rightItems[0], rightItems[1], rightItems[2]).asElem()),
+			newDigit(meas, rightItems[3], v),
 		)
 	}
-	digits := make([]any, len(rightItems)+1)
-	copy(digits, rightItems)
-	digits[len(rightItems)] = v
 	return newDeepTree(
 		meas,
 		d.left,
 		d.mid,
-		newDigit(meas, digits),
+		d.right.addLast(meas, v),
 	)
 }
 
-func (d *deepTree) RemoveFirst() fingerTree {
-	meas := measurerFor(d)
+func (d *deepTree[V, M]) RemoveFirst() fingerTree[V, M] {
+	meas := d.measurer
 	if d.left.len() > 1 {
-		return newDeepTree(meas, d.left.removeFirst(), d.mid, d.right)
+		return newDeepTree(meas, d.left.removeFirst(meas), d.mid, d.right)
 	} else if !isEmpty(d.mid) {
-		newMid := newDelayed(func() fingerTree { return d.mid.RemoveFirst() })
+		newMid := newDelayed(func() fingerTree[V, M] { return d.mid.RemoveFirst() })
 		midFirst := d.mid.PeekFirst()
-		return newDeepTree(meas, asNode(midFirst).toDigit(), newMid, d.right)
+		return newDeepTree(meas, midFirst.asNode().toDigit(), newMid, d.right)
 	} else if d.right.len() == 1 {
 		return newSingleTree(meas, d.right.items[0])
 	}
-	return newDeepTree(meas, d.right.slice(0, 1), d.mid, d.right.removeFirst())
+	return newDeepTree(meas, d.right.slice(meas, 0, 1), d.mid, d.right.removeFirst(meas))
 }
 
-func (d *deepTree) RemoveLast() fingerTree {
-	meas := measurerFor(d)
+func (d *deepTree[V, M]) RemoveLast() fingerTree[V, M] {
+	meas := d.measurer
 	if d.right.len() > 1 {
-		return newDeepTree(meas, d.left, d.mid, d.right.removeLast())
+		return newDeepTree(meas, d.left, d.mid, d.right.removeLast(meas))
 	} else if !isEmpty(d.mid) {
-		newMid := newDelayed(func() fingerTree { return d.mid.RemoveLast() })
+		newMid := newDelayed(func() fingerTree[V, M] { return d.mid.RemoveLast() })
 		last := d.mid.PeekLast()
-		return newDeepTree(meas, d.left, newMid, asNode(last).toDigit())
+		return newDeepTree(meas, d.left, newMid, last.asNode().toDigit())
 	} else if d.left.len() == 1 {
 		return newSingleTree(meas, d.left.items[0])
 	}
-	return newDeepTree(meas, d.left.removeLast(), d.mid, d.left.slice(d.left.len()-1, d.left.len()))
+	return newDeepTree(meas, d.left.removeLast(meas), d.mid, d.left.slice(meas, d.left.len()-1, d.left.len()))
 }
 
-func (d *deepTree) PeekFirst() any {
+func (d *deepTree[V, M]) PeekFirst() elem[V, M] {
 	return d.left.peekFirst()
 }
 
-func (d *deepTree) PeekLast() any {
+func (d *deepTree[V, M]) PeekLast() elem[V, M] {
 	return d.right.peekLast()
 }
 
-func (d *deepTree) Concat(other fingerTree) fingerTree {
+func (d *deepTree[V, M]) Concat(other fingerTree[V, M]) fingerTree[V, M] {
 	other = force(other)
 	if isEmpty(other) {
 		return d
-	} else if s, ok := other.(*singleTree); ok {
+	} else if s, ok := other.(*singleTree[V, M]); ok {
 		return d.AddLast(s.value)
 	}
-	return app3(d, []*node{}, other)
+	return app3(d, nil, other)
 }
 
-func (d *deepTree) Split(predicate predicate) (fingerTree, fingerTree) {
-	meas := d.measurement().value
-	measurer := measurerFor(d)
-	if predicate(meas) {
+func (d *deepTree[V, M]) Split(predicate Predicate[M]) (fingerTree[V, M], fingerTree[V, M]) {
+	measurer := d.measurer
+	if _, ok := holds(measurer, d, predicate, measurer.Identity()); ok {
 		left, mid, right := d.splitTree(predicate, measurer.Identity())
 		return left, right.AddFirst(mid)
 	}
 	return d, newEmptyTree(measurer)
 }
 
-func (d *deepTree) ToSlice() []any {
-	result := make([]any, 0, 8)
-	d.Each(func(value any) bool {
+func (d *deepTree[V, M]) ToSlice() []V {
+	result := make([]V, 0, 8)
+	d.Each(func(value V) bool {
 		result = append(result, value)
 		return true
 	})
 	return result
 }
 
-func (d *deepTree) Each(f iterFunc) bool {
+func (d *deepTree[V, M]) Each(f IterFunc[V]) bool {
 	if d.left.Each(f) {
 		if d.mid.Each(f) {
 			return d.right.Each(f)
@@ -182,7 +176,7 @@ func (d *deepTree) Each(f iterFunc) bool {
 	return false
 }
 
-func (d *deepTree) EachReverse(f iterFunc) bool {
+func (d *deepTree[V, M]) EachReverse(f IterFunc[V]) bool {
 	if d.right.EachReverse(f) {
 		if d.mid.EachReverse(f) {
 			return d.left.EachReverse(f)
@@ -193,58 +187,115 @@ func (d *deepTree) EachReverse(f iterFunc) bool {
 
 // Helper function to split the tree into 3 parts.
 // middle value could be
-func (d *deepTree) splitTree(predicate predicate, initial any) (fingerTree, any, fingerTree) {
-	meas := measurerFor(d)
-	leftMeasure := meas.Sum(initial, d.left._measurement.value)
+func (d *deepTree[V, M]) splitTree(predicate Predicate[M], initial M) (fingerTree[V, M], elem[V, M], fingerTree[V, M]) {
+	meas := d.measurer
 	// see if the split point is inside the left tree
-	if predicate(leftMeasure) {
-		left, mid, right := d.left.dsplit(predicate, initial)
-		return fromArray(meas, left), mid, deepLeft(meas, right, d.mid, d.right)
+	leftMeasure, inLeft := holdsDigit(meas, d.left, predicate, initial)
+	if inLeft {
+		left, mid, right := d.left.dsplit(meas, predicate, initial)
+		return fromElems(meas, left), mid, deepLeft(meas, right, d.mid, d.right)
 	}
-	midMeasure := meas.Sum(leftMeasure, d.mid.measurement().value)
 	// see if the split point is inside the mid tree
-	if predicate(midMeasure) {
+	midMeasure, inMid := holds(meas, d.mid, predicate, leftMeasure)
+	if inMid {
 		mleft, mmid, mright := d.mid.splitTree(predicate, leftMeasure)
-		left, mid, right := asNode(mmid).toDigit().dsplit(predicate, meas.Sum(leftMeasure, mleft.measurement().value))
+		left, mid, right := splitElems(meas, mmid.asNode().elems(), predicate, meas.Sum(leftMeasure, mleft.measurement()))
 		return deepRight(meas, d.left, mleft, left),
 			mid,
 			deepLeft(meas, right, mright, d.right)
 	}
 	// the split point is in the right tree
-	left, mid, right := d.right.dsplit(predicate, midMeasure)
+	left, mid, right := d.right.dsplit(meas, predicate, midMeasure)
 	return deepRight(meas, d.left, d.mid, left),
 		mid,
-		fromArray(meas, right)
+		fromElems(meas, right)
+}
+
+// Helper function to find the element where the predicate first holds without
+// building any trees. The middle value is a node when d is a mid tree.
+func (d *deepTree[V, M]) lookup(predicate Predicate[M], initial M) (M, elem[V, M]) {
+	meas := d.measurer
+	leftMeasure, inLeft := holdsDigit(meas, d.left, predicate, initial)
+	if inLeft {
+		return lookupElems(meas, d.left.elems(), predicate, initial)
+	}
+	midMeasure, inMid := holds(meas, d.mid, predicate, leftMeasure)
+	if inMid {
+		prefix, n := d.mid.lookup(predicate, leftMeasure)
+		return lookupElems(meas, n.asNode().elems(), predicate, prefix)
+	}
+	return lookupElems(meas, d.right.elems(), predicate, midMeasure)
+}
+
+// Like splitTree but scanning from the right with suffix measures
+func (d *deepTree[V, M]) splitTreeRight(predicate Predicate[M], initial M) (fingerTree[V, M], elem[V, M], fingerTree[V, M]) {
+	meas := d.measurer
+	// see if the split point is inside the right tree
+	rightMeasure, inRight := holdsDigitRight(meas, d.right, predicate, initial)
+	if inRight {
+		left, mid, right := splitElemsRight(meas, d.right.elems(), predicate, initial)
+		return deepRight(meas, d.left, d.mid, left), mid, fromElems(meas, right)
+	}
+	// see if the split point is inside the mid tree
+	midMeasure, inMid := holdsRight(meas, d.mid, predicate, rightMeasure)
+	if inMid {
+		mleft, mmid, mright := d.mid.splitTreeRight(predicate, rightMeasure)
+		left, mid, right := splitElemsRight(meas, mmid.asNode().elems(), predicate, meas.Sum(mright.measurement(), rightMeasure))
+		return deepRight(meas, d.left, mleft, left),
+			mid,
+			deepLeft(meas, right, mright, d.right)
+	}
+	// the split point is in the left tree
+	left, mid, right := splitElemsRight(meas, d.left.elems(), predicate, midMeasure)
+	return fromElems(meas, left),
+		mid,
+		deepLeft(meas, right, d.mid, d.right)
 }
 
-func deepLeft(meas measurer, left []any, mid fingerTree, right *digit) fingerTree {
+// Like lookup but scanning from the right, returning the measure of the
+// values after the element it finds
+func (d *deepTree[V, M]) lookupRight(predicate Predicate[M], initial M) (M, elem[V, M]) {
+	meas := d.measurer
+	rightMeasure, inRight := holdsDigitRight(meas, d.right, predicate, initial)
+	if inRight {
+		return lookupElemsRight(meas, d.right.elems(), predicate, initial)
+	}
+	midMeasure, inMid := holdsRight(meas, d.mid, predicate, rightMeasure)
+	if inMid {
+		suffix, n := d.mid.lookupRight(predicate, rightMeasure)
+		return lookupElemsRight(meas, n.asNode().elems(), predicate, suffix)
+	}
+	return lookupElemsRight(meas, d.left.elems(), predicate, midMeasure)
+}
+
+func deepLeft[V, M any](meas Measurer[V, M], left []elem[V, M], mid fingerTree[V, M], right *digit[V, M]) fingerTree[V, M] {
 	if len(left) == 0 {
 		if isEmpty(mid) {
-			return fromArray(meas, right.items)
+			return fromElems(meas, right.elems())
 		}
-		return newDelayed(func() fingerTree {
+		return newDelayed(func() fingerTree[V, M] {
 			return newDeepTree(meas,
-				asNode(mid.PeekFirst()).toDigit(),
+				mid.PeekFirst().asNode().toDigit(),
 				mid.RemoveFirst(),
 				right)
 		})
 	}
-	return newDeepTree(meas, newDigit(meas, left), mid, right)
+	return newDeepTree(meas, newDigit(meas, left...), mid, right)
 }
 
-func deepRight(meas measurer, left *digit, mid fingerTree, right []any) fingerTree {
+func deepRight[V, M any](meas Measurer[V, M], left *digit[V, M], mid fingerTree[V, M], right []elem[V, M]) fingerTree[V, M] {
 	if len(right) == 0 {
 		if isEmpty(mid) {
-			return fromArray(meas, left.items)
+			return fromElems(meas, left.elems())
 		}
-		return newDelayed(func() fingerTree {
+		return newDelayed(func() fingerTree[V, M] {
 			return newDeepTree(meas,
 				left,
 				mid.RemoveLast(),
-				asNode(mid.PeekLast()).toDigit())
+				mid.PeekLast().asNode().toDigit())
 		})
 	}
-	return newDeepTree(meas, left, mid, newDigit(meas, right))
+	return newDeepTree(meas, left, mid, newDigit(meas, right...))
 }
 
 // Helper function to concatenate two finger-trees with additional elements
@@ -253,43 +304,35 @@ func deepRight(meas measurer, left *digit, mid fingerTree, right []any) fingerTr
 // ts: An array of elements in between the two finger-trees
 // t2: Right finger-tree
 // returns a new FingerTree
-func app3(t1 fingerTree, items []*node, t2 fingerTree) fingerTree {
+func app3[V, M any](t1 fingerTree[V, M], items []elem[V, M], t2 fingerTree[V, M]) fingerTree[V, M] {
 	t1 = force(t1)
 	t2 = force(t2)
 	if isEmpty(t1) {
 		return prependTree(t2, items)
 	} else if isEmpty(t2) {
 		return appendTree(t1, items)
-	} else if s, ok := t1.(*singleTree); ok {
+	} else if s, ok := t1.(*singleTree[V, M]); ok {
 		return prependTree(t2, items).AddFirst(s.value)
-	} else if s, ok := t2.(*singleTree); ok {
+	} else if s, ok := t2.(*singleTree[V, M]); ok {
 		return appendTree(t1, items).AddLast(s.value)
 	}
-	d1, _ := t1.(*deepTree)
-	d2, _ := t2.(*deepTree)
+	d1, _ := t1.(*deepTree[V, M])
+	d2, _ := t2.(*deepTree[V, M])
 	return newDeepTree(
-		measurerFor(d1),
+		d1.measurer,
 		d1.left,
-		newDelayed(func() fingerTree {
+		newDelayed(func() fingerTree[V, M] {
 			return app3(
 				d1.mid,
-				nodes(measurerFor(d1), concat3(d1.right.items, items, d2.left.items)),
+				nodes(d1.measurer, concat3(d1.right.elems(), items, d2.left.elems())),
 				d2.mid)
 		}),
 		d2.right)
 }
 
-func appendAll[V any](result []any, slice []V) []any {
-	for i := range slice {
-		result = result[:len(result)+1]
-		result[len(result)-1] = slice[i]
-	}
-	return result
-}
-
-func concat3[A, B, C any](s1 []A, s2 []B, s3 []C) []any {
-	result := make([]any, 0, len(s1)+len(s2)+len(s3))
-	result = appendAll(result, s1)
-	result = appendAll(result, s2)
-	return appendAll(result, s3)
+func concat3[V, M any](s1 []elem[V, M], s2 []elem[V, M], s3 []elem[V, M]) []elem[V, M] {
+	result := make([]elem[V, M], 0, len(s1)+len(s2)+len(s3))
+	result = append(result, s1...)
+	result = append(result, s2...)
+	return append(result, s3...)
 }
@@ -5,82 +5,103 @@ import (
 	"io"
 )
 
-type fingerTreeFunc func() fingerTree
+type fingerTreeFunc[V, M any] func() fingerTree[V, M]
 
-type delayed struct {
-	f           fingerTreeFunc
-	delayedTree fingerTree
+// A delayed is a finger tree that is computed the first time it is used.
+// Forcing is safe to do from several goroutines at once.
+type delayed[V, M any] struct {
+	f           fingerTreeFunc[V, M]
+	delayedTree lazy[fingerTree[V, M]]
 }
 
-func newDelayed(f fingerTreeFunc) *delayed {
-	tree := &delayed{f: f}
-	tree.delayedTree = tree
-	return tree
+func newDelayed[V, M any](f fingerTreeFunc[V, M]) *delayed[V, M] {
+	return &delayed[V, M]{f: f}
 }
 
-func (f *delayed) String() string {
+func (f *delayed[V, M]) String() string {
 	return fmt.Sprintf("delayed{%s}", f.force())
 }
 
-func (f *delayed) Dump(w io.Writer, level int) {
+func (f *delayed[V, M]) Dump(w io.Writer, level int) {
 	f.force().Dump(w, level)
 }
 
-func (f *delayed) force() fingerTree {
-	if f.delayedTree == f {
-		f.delayedTree = f.f()
-	}
-	return f.delayedTree
+func (f *delayed[V, M]) force() fingerTree[V, M] {
+	return f.delayedTree.get(f.compute)
+}
+
+// called at most once, under the lazy's lock
+func (f *delayed[V, M]) compute() fingerTree[V, M] {
+	tree := force(f.f())
+	// release the closure so it doesn't pin the trees it captured
+	f.f = nil
+	return tree
 }
 
-func (f *delayed) splitTree(predicate predicate, initial any) (fingerTree, any, fingerTree) {
+func (f *delayed[V, M]) splitTree(predicate Predicate[M], initial M) (fingerTree[V, M], elem[V, M], fingerTree[V, M]) {
 	return f.force().splitTree(predicate, initial)
 }
 
-func (f *delayed) measurement() measurement {
+func (f *delayed[V, M]) lookup(predicate Predicate[M], initial M) (M, elem[V, M]) {
+	return f.force().lookup(predicate, initial)
+}
+
+func (f *delayed[V, M]) splitTreeRight(predicate Predicate[M], initial M) (fingerTree[V, M], elem[V, M], fingerTree[V, M]) {
+	return f.force().splitTreeRight(predicate, initial)
+}
+
+func (f *delayed[V, M]) lookupRight(predicate Predicate[M], initial M) (M, elem[V, M]) {
+	return f.force().lookupRight(predicate, initial)
+}
+
+func (f *delayed[V, M]) measurement() M {
 	return f.force().measurement()
 }
 
-func (f *delayed) AddFirst(value any) fingerTree {
+func (f *delayed[V, M]) getMeasurer() Measurer[V, M] {
+	return f.force().getMeasurer()
+}
+
+func (f *delayed[V, M]) AddFirst(value elem[V, M]) fingerTree[V, M] {
 	return f.force().AddFirst(value)
 }
 
-func (f *delayed) AddLast(value any) fingerTree {
+func (f *delayed[V, M]) AddLast(value elem[V, M]) fingerTree[V, M] {
 	return f.force().AddLast(value)
 }
 
-func (f *delayed) RemoveFirst() fingerTree {
+func (f *delayed[V, M]) RemoveFirst() fingerTree[V, M] {
 	return f.force().RemoveFirst()
 }
 
-func (f *delayed) RemoveLast() fingerTree {
+func (f *delayed[V, M]) RemoveLast() fingerTree[V, M] {
 	return f.force().RemoveLast()
 }
 
-func (f *delayed) PeekFirst() any {
+func (f *delayed[V, M]) PeekFirst() elem[V, M] {
 	return f.force().PeekFirst()
 }
 
-func (f *delayed) PeekLast() any {
+func (f *delayed[V, M]) PeekLast() elem[V, M] {
 	return f.force().PeekLast()
 }
 
-func (f *delayed) Concat(other fingerTree) fingerTree {
+func (f *delayed[V, M]) Concat(other fingerTree[V, M]) fingerTree[V, M] {
 	return f.force().Concat(other)
 }
 
-func (f *delayed) Split(predicate predicate) (fingerTree, fingerTree) {
+func (f *delayed[V, M]) Split(predicate Predicate[M]) (fingerTree[V, M], fingerTree[V, M]) {
 	return f.force().Split(predicate)
 }
 
-func (f *delayed) ToSlice() []any {
+func (f *delayed[V, M]) ToSlice() []V {
 	return f.force().ToSlice()
 }
 
-func (f *delayed) Each(fun iterFunc) bool {
+func (f *delayed[V, M]) Each(fun IterFunc[V]) bool {
 	return f.force().Each(fun)
 }
 
-func (f *delayed) EachReverse(fun iterFunc) bool {
+func (f *delayed[V, M]) EachReverse(fun IterFunc[V]) bool {
 	return f.force().EachReverse(fun)
 }
@@ -0,0 +1,376 @@
+package lazyfingertree
+
+import (
+	"math"
+	"math/bits"
+)
+
+// Diff compares two versions of a tree by walking them together and skipping
+// the subtrees they share. Versions made from each other with Split, Concat,
+// and the other operations share most of their deep trees, digits, and nodes,
+// so comparing them costs time in proportion to the size of the changes times
+// the depth of the tree.
+//
+// Trees that were built separately share no structure. If their measures are
+// [Hashed], subtrees with equal hashes are treated as equal so they can still
+// be skipped. Their values are not compared, so this is probabilistic: two
+// different sequences of n values have the same hash with a chance of about
+// n in 2^61, and if that happens the edits inside them are missed.
+
+// A Hashed measure pairs a measure with a polynomial hash of the values it
+// covers, modulo the prime 2^61-1. The hash of a concatenation is computed
+// from the hashes of its parts, so equal sequences have equal hashes however
+// they are split into nodes. Different sequences usually have different
+// hashes, but not always.
+type Hashed[M any] struct {
+	Measure M
+	Digest  uint64
+	// base to the power of the number of values, used to combine hashes
+	Pow uint64
+}
+
+type contentHasher interface {
+	contentHash() [2]uint64
+}
+
+func (h Hashed[M]) contentHash() [2]uint64 {
+	return [2]uint64{h.Digest, h.Pow}
+}
+
+// Hashes are computed modulo a Mersenne prime. Modulo 2^64, sequences like
+// Thue-Morse and its complement always collide.
+const hashPrime = 1<<61 - 1
+
+// any value in 2..hashPrime-2 works, this one has no pattern in its bits
+const hashBase = 0x1e3779b97f4a7c15
+
+func mulMod(a, b uint64) uint64 {
+	hi, lo := bits.Mul64(a, b)
+	// 2^61 is 1 modulo the prime, so add the bits above 61 to the bits below
+	return reduce(hi<<3|lo>>61, lo&hashPrime)
+}
+
+// Return (a + b) modulo the prime for a, b < 2^62
+func reduce(a, b uint64) uint64 {
+	r := a + b
+	r = r&hashPrime + r>>61
+	if r >= hashPrime {
+		r -= hashPrime
+	}
+	return r
+}
+
+// A HashedMeasurer adds a hash to the measures of another measurer. Hash must
+// return the same value for equal values, [hash/maphash.Comparable] works for
+// comparable types.
+type HashedMeasurer[V, M any] struct {
+	measurer Measurer[V, M]
+	hash     func(V) uint64
+}
+
+func NewHashedMeasurer[V, M any](measurer Measurer[V, M], hash func(V) uint64) HashedMeasurer[V, M] {
+	return HashedMeasurer[V, M]{measurer, hash}
+}
+
+func (h HashedMeasurer[V, M]) Identity() Hashed[M] {
+	return Hashed[M]{h.measurer.Identity(), 0, 1}
+}
+
+func (h HashedMeasurer[V, M]) Measure(value V) Hashed[M] {
+	// splitmix64 finalizer to spread out weak hashes
+	x := h.hash(value)
+	x = (x ^ (x >> 30)) * 0xbf58476d1ce4e5b9
+	x = (x ^ (x >> 27)) * 0x94d049bb133111eb
+	return Hashed[M]{h.measurer.Measure(value), (x ^ (x >> 31)) % hashPrime, hashBase}
+}
+
+func (h HashedMeasurer[V, M]) Sum(a, b Hashed[M]) Hashed[M] {
+	return Hashed[M]{h.measurer.Sum(a.Measure, b.Measure), reduce(mulMod(a.Digest, b.Pow), b.Digest), mulMod(a.Pow, b.Pow)}
+}
+
+// An Edit replaces the Deleted values, which start at APos in the old tree,
+// with the Inserted values, which start at BPos in the new tree. The
+// positions are the measures of everything before the edit.
+type Edit[V, M any] struct {
+	APos, BPos M
+	Deleted    []V
+	Inserted   []V
+}
+
+// Return the edits that turn a into b, in order
+func Diff[MS Measurer[V, M], V comparable, M any](a, b FingerTree[MS, V, M]) []Edit[V, M] {
+	return DiffFunc(a, b, func(x, y V) bool { return x == y })
+}
+
+// Return the edits that turn a into b, in order, comparing values with eq
+func DiffFunc[MS Measurer[V, M], V, M any](a, b FingerTree[MS, V, M], eq func(V, V) bool) []Edit[V, M] {
+	d := &differ[V, M]{measurer: a.measurerOrZero(), eq: eq}
+	if a.f == nil {
+		d.measurer = b.measurerOrZero()
+	}
+	var as, bs []diffItem[V, M]
+	if a.f != nil {
+		as = []diffItem[V, M]{{tree: a.forward()}}
+	}
+	if b.f != nil {
+		bs = []diffItem[V, M]{{tree: b.forward()}}
+	}
+	d.diff(as, bs, d.measurer.Identity(), d.measurer.Identity())
+	return d.edits
+}
+
+// A diffItem is an unexpanded part of a tree: a tree, a digit, a node, or a
+// value. Depth is the depth of the tree the item is in, or for a node, its
+// height.
+type diffItem[V, M any] struct {
+	tree  fingerTree[V, M]
+	digit *digit[V, M]
+	node  *node[V, M]
+	value V
+	depth int
+}
+
+// items with higher ranks contain items with lower ranks
+func (it diffItem[V, M]) rank() int {
+	if it.tree != nil {
+		return math.MaxInt
+	} else if it.digit != nil {
+		return 2*it.depth + 1
+	} else if it.node != nil {
+		return 2 * it.depth
+	}
+	return 0
+}
+
+func (it diffItem[V, M]) isLeaf() bool {
+	return it.rank() == 0
+}
+
+func (it diffItem[V, M]) identity() any {
+	if it.tree != nil {
+		return it.tree
+	} else if it.digit != nil {
+		return it.digit
+	} else if it.node != nil {
+		return it.node
+	}
+	return nil
+}
+
+func elemItem[V, M any](e elem[V, M], depth int) diffItem[V, M] {
+	if e.node != nil {
+		return diffItem[V, M]{node: e.node, depth: depth}
+	}
+	return diffItem[V, M]{value: e.value}
+}
+
+type differ[V, M any] struct {
+	measurer Measurer[V, M]
+	eq       func(V, V) bool
+	edits    []Edit[V, M]
+}
+
+func (d *differ[V, M]) measure(it diffItem[V, M]) M {
+	if it.tree != nil {
+		return it.tree.measurement()
+	} else if it.digit != nil {
+		return it.digit.getMeasurement()
+	} else if it.node != nil {
+		return it.node.measurement()
+	}
+	return d.measurer.Measure(it.value)
+}
+
+func (d *differ[V, M]) sum(items []diffItem[V, M]) M {
+	result := d.measurer.Identity()
+	for _, it := range items {
+		result = d.measurer.Sum(result, d.measure(it))
+	}
+	return result
+}
+
+// Only digits and nodes are hashed, trees would have to be forced to hash
+// them, and leaves are compared with eq
+func (d *differ[V, M]) hash(it diffItem[V, M]) ([2]uint64, bool) {
+	if it.digit == nil && it.node == nil {
+		return [2]uint64{}, false
+	}
+	h, ok := any(d.measure(it)).(contentHasher)
+	if !ok {
+		return [2]uint64{}, false
+	}
+	return h.contentHash(), true
+}
+
+func (d *differ[V, M]) same(x, y diffItem[V, M]) bool {
+	if x.isLeaf() || y.isLeaf() {
+		return x.isLeaf() && y.isLeaf() && d.eq(x.value, y.value)
+	} else if x.identity() == y.identity() {
+		return true
+	}
+	hx, okx := d.hash(x)
+	hy, oky := d.hash(y)
+	return okx && oky && hx == hy
+}
+
+// Return the item's parts, in a new slice
+func (d *differ[V, M]) expand(it diffItem[V, M]) []diffItem[V, M] {
+	var result []diffItem[V, M]
+	if it.tree != nil {
+		switch t := force(it.tree).(type) {
+		case *singleTree[V, M]:
+			result = append(result, elemItem(t.value, it.depth))
+		case *deepTree[V, M]:
+			result = append(result,
+				diffItem[V, M]{digit: t.left, depth: it.depth},
+				diffItem[V, M]{tree: t.mid, depth: it.depth + 1},
+				diffItem[V, M]{digit: t.right, depth: it.depth})
+		}
+	} else if it.digit != nil {
+		for _, e := range it.digit.elems() {
+			result = append(result, elemItem(e, it.depth))
+		}
+	} else if it.node != nil {
+		for _, e := range it.node.elems() {
+			result = append(result, elemItem(e, it.depth-1))
+		}
+	}
+	return result
+}
+
+// Expand the items with the given rank
+func (d *differ[V, M]) expandRank(items []diffItem[V, M], rank int) []diffItem[V, M] {
+	result := make([]diffItem[V, M], 0, len(items)*3)
+	for _, it := range items {
+		if !it.isLeaf() && it.rank() == rank {
+			result = append(result, d.expand(it)...)
+		} else {
+			result = append(result, it)
+		}
+	}
+	return result
+}
+
+func (d *differ[V, M]) emit(apos, bpos M, as, bs []diffItem[V, M]) {
+	edit := Edit[V, M]{APos: apos, BPos: bpos, Deleted: d.values(as), Inserted: d.values(bs)}
+	if len(edit.Deleted) > 0 || len(edit.Inserted) > 0 {
+		d.edits = append(d.edits, edit)
+	}
+}
+
+func (d *differ[V, M]) values(items []diffItem[V, M]) []V {
+	var result []V
+	collect := func(v V) bool {
+		result = append(result, v)
+		return true
+	}
+	for _, it := range items {
+		if it.tree != nil {
+			it.tree.Each(collect)
+		} else if it.digit != nil {
+			it.digit.Each(collect)
+		} else if it.node != nil {
+			it.node.Each(collect)
+		} else {
+			result = append(result, it.value)
+		}
+	}
+	return result
+}
+
+// Find the first item in as that matches an item in bs by identity or hash
+func (d *differ[V, M]) anchor(as, bs []diffItem[V, M]) (int, int) {
+	positions := map[any]int{}
+	for j, it := range bs {
+		if it.isLeaf() {
+			continue
+		}
+		if _, ok := positions[it.identity()]; !ok {
+			positions[it.identity()] = j
+		}
+		if h, ok := d.hash(it); ok {
+			if _, ok := positions[h]; !ok {
+				positions[h] = j
+			}
+		}
+	}
+	for i, it := range as {
+		if it.isLeaf() {
+			continue
+		} else if j, ok := positions[it.identity()]; ok {
+			return i, j
+		} else if h, ok := d.hash(it); ok {
+			if j, ok := positions[h]; ok {
+				return i, j
+			}
+		}
+	}
+	return -1, -1
+}
+
+func (d *differ[V, M]) diff(as, bs []diffItem[V, M], apos, bpos M) {
+	meas := d.measurer
+	for {
+		// skip the common prefix, expanding the larger item when the fronts differ
+		for len(as) > 0 && len(bs) > 0 {
+			x, y := as[0], bs[0]
+			if d.same(x, y) {
+				apos = meas.Sum(apos, d.measure(x))
+				bpos = meas.Sum(bpos, d.measure(y))
+				as, bs = as[1:], bs[1:]
+				continue
+			} else if x.isLeaf() && y.isLeaf() {
+				break
+			}
+			rx, ry := x.rank(), y.rank()
+			if rx >= ry {
+				as = append(d.expand(x), as[1:]...)
+			}
+			if ry >= rx {
+				bs = append(d.expand(y), bs[1:]...)
+			}
+		}
+		// skip the common suffix
+		for len(as) > 0 && len(bs) > 0 {
+			x, y := as[len(as)-1], bs[len(bs)-1]
+			if d.same(x, y) {
+				as, bs = as[:len(as)-1], bs[:len(bs)-1]
+				continue
+			} else if x.isLeaf() && y.isLeaf() {
+				break
+			}
+			rx, ry := x.rank(), y.rank()
+			if rx >= ry {
+				as = append(as[:len(as)-1:len(as)-1], d.expand(x)...)
+			}
+			if ry >= rx {
+				bs = append(bs[:len(bs)-1:len(bs)-1], d.expand(y)...)
+			}
+		}
+		if len(as) == 0 || len(bs) == 0 {
+			d.emit(apos, bpos, as, bs)
+			return
+		}
+		// the middles may still share subtrees, split the edit around one
+		if i, j := d.anchor(as, bs); i >= 0 {
+			d.diff(as[:i:i], bs[:j:j], apos, bpos)
+			apos = meas.Sum(apos, d.sum(as[:i+1]))
+			bpos = meas.Sum(bpos, d.sum(bs[:j+1]))
+			as, bs = as[i+1:], bs[j+1:]
+			continue
+		}
+		top := 0
+		for _, it := range as {
+			top = max(top, it.rank())
+		}
+		for _, it := range bs {
+			top = max(top, it.rank())
+		}
+		if top == 0 {
+			d.emit(apos, bpos, as, bs)
+			return
+		}
+		as = d.expandRank(as, top)
+		bs = d.expandRank(bs, top)
+	}
+}
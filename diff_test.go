@@ -0,0 +1,116 @@
+package lazyfingertree
+
+import (
+	"hash/maphash"
+	"math/bits"
+	"math/rand"
+	"slices"
+	"testing"
+)
+
+// apply edits positioned by width to the values of the old tree
+func applyEdits(old []int, edits []Edit[int, int]) []int {
+	var result []int
+	pos := 0
+	for _, edit := range edits {
+		result = append(result, old[pos:edit.APos]...)
+		result = append(result, edit.Inserted...)
+		pos = edit.APos + len(edit.Deleted)
+	}
+	return append(result, old[pos:]...)
+}
+
+func replaceAt(tree FingerTree[width[int, int], int, int], i, value int) FingerTree[width[int, int], int, int] {
+	left, right := tree.Split(func(m int) bool { return m > i })
+	return left.AddLast(value).Concat(right.RemoveFirst())
+}
+
+func TestDiffSharedVersions(t *testing.T) {
+	v1 := lazyTree(100000)
+	v2 := replaceAt(replaceAt(v1, 100, -1), 90000, -2)
+	left, right := v2.Split(func(m int) bool { return m > 50000 })
+	v2 = left.Concat(newTree(-3, -4)).Concat(right)
+	edits := Diff(v1, v2)
+	if len(edits) != 3 {
+		t.Fatalf("expected 3 edits, got %v", edits)
+	}
+	failIfNot(t, edits[0].APos == 100 && edits[0].BPos == 100 && same(edits[0].Inserted, []int{-1}))
+	failIfNot(t, edits[1].APos == 50000 && len(edits[1].Deleted) == 0 && same(edits[1].Inserted, []int{-3, -4}))
+	failIfNot(t, edits[2].APos == 90000 && edits[2].BPos == 90002)
+	failIfNot(t, same(applyEdits(v1.ToSlice(), edits), v2.ToSlice()))
+	failIfNot(t, len(Diff(v1, v1)) == 0)
+	failIfNot(t, same(applyEdits(nil, Diff(newTree[int](), v1)), v1.ToSlice()))
+}
+
+func TestDiffRandom(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for range 200 {
+		v1 := lazyTree(rng.Intn(500))
+		v2 := v1
+		for range rng.Intn(4) + 1 {
+			n := v2.Measure()
+			i := rng.Intn(n + 1)
+			left, right := v2.Split(func(m int) bool { return m > i })
+			if rng.Intn(2) == 0 && !right.IsEmpty() {
+				right = right.RemoveFirst()
+			} else {
+				left = left.AddLast(-rng.Intn(10))
+			}
+			v2 = left.Concat(right)
+		}
+		edits := Diff(v1, v2)
+		if !same(applyEdits(v1.ToSlice(), edits), v2.ToSlice()) {
+			t.Fatalf("edits %v do not turn %v into %v", edits, v1.ToSlice(), v2.ToSlice())
+		}
+	}
+}
+
+func TestDiffHashed(t *testing.T) {
+	seed := maphash.MakeSeed()
+	ms := NewHashedMeasurer(newWidth[int](), func(v int) uint64 { return maphash.Comparable(seed, v) })
+	values := make([]int, 10000)
+	for i := range values {
+		values[i] = i
+	}
+	a := FromArray(ms, values)
+	changed := slices.Clone(values)
+	changed[5000] = -1
+	// built separately, the trees share no nodes
+	b := FromArray(ms, changed)
+	var count int
+	edits := DiffFunc(a, b, func(x, y int) bool {
+		count++
+		return x == y
+	})
+	if len(edits) != 1 || edits[0].APos.Measure != 5000 || !same(edits[0].Inserted, []int{-1}) {
+		t.Fatalf("bad edits: %v", edits)
+	}
+	if count > 200 {
+		t.Fatalf("compared %d values", count)
+	}
+	failIfNot(t, a.Measure().contentHash() != b.Measure().contentHash())
+	failIfNot(t, FromArray(ms, changed).Measure() == b.Measure())
+}
+
+// Polynomial hashes modulo 2^64 give a Thue-Morse sequence and its complement
+// the same hash for any odd base
+func TestDiffHashedThueMorse(t *testing.T) {
+	seed := maphash.MakeSeed()
+	ms := NewHashedMeasurer(newWidth[int](), func(v int) uint64 { return maphash.Comparable(seed, v) })
+	values := make([]int, 4096)
+	complement := make([]int, len(values))
+	for i := range values {
+		values[i] = bits.OnesCount(uint(i)) % 2
+		complement[i] = 1 - values[i]
+	}
+	a := FromArray(ms, values)
+	b := FromArray(ms, complement)
+	failIfNot(t, a.Measure().Digest != b.Measure().Digest)
+	var edits []Edit[int, int]
+	for _, edit := range Diff(a, b) {
+		edits = append(edits, Edit[int, int]{edit.APos.Measure, edit.BPos.Measure, edit.Deleted, edit.Inserted})
+	}
+	if len(edits) == 0 || !same(applyEdits(values, edits), complement) {
+		t.Fatalf("edits do not turn the sequence into its complement: %v", edits)
+	}
+}
@@ -7,24 +7,32 @@ import (
 
 // A digit is a measured container of one to four elements.
 // this is not a FingerTree, it only shares some of the methods
-type digit struct {
-	_measurement measurement
-	items        []any
+type digit[V, M any] struct {
+	// nil unless the measure is computed when first used
+	measure      *lazyMeasure[M]
+	_measurement M
+	size         int
+	items        [4]elem[V, M]
 }
 
-func newDigit(measurer measurer, items []any) *digit {
-	m := measurer.Identity()
-	for _, item := range items {
-		m = measurer.Sum(m, measurer.Measure(item))
+func newDigit[V, M any](measurer Measurer[V, M], items ...elem[V, M]) *digit[V, M] {
+	d := &digit[V, M]{size: len(items)}
+	copy(d.items[:], items)
+	if m, ok := knownSum(measurer, items); ok {
+		d._measurement = m
+	} else {
+		d.measure = &lazyMeasure[M]{sum: func() M {
+			return sumElems(measurer, measurer.Identity(), d.elems())
+		}}
 	}
-	return &digit{measurement{measurer, m}, items}
+	return d
 }
 
-func (d *digit) String() string {
+func (d *digit[V, M]) String() string {
 	var b strings.Builder
 	first := true
 	b.WriteString("digit{")
-	for _, i := range d.items {
+	for _, i := range d.elems() {
 		if first {
 			first = false
 		} else {
@@ -36,57 +44,84 @@ func (d *digit) String() string {
 	return b.String()
 }
 
-func (d *digit) len() int {
-	return len(d.items)
+// The digit's items. The result must not be modified.
+func (d *digit[V, M]) elems() []elem[V, M] {
+	return d.items[:d.size]
 }
 
-func (d *digit) getMeasurement() measurement {
+func (d *digit[V, M]) len() int {
+	return d.size
+}
+
+func (d *digit[V, M]) getMeasurement() M {
+	if d.measure != nil {
+		return d.measure.get()
+	}
 	return d._measurement
 }
 
-func (d *digit) removeFirst() *digit {
-	return d.slice(1, len(d.items))
+// Return the digit's measure if it is known without measuring its items
+func (d *digit[V, M]) knownMeasure() (M, bool) {
+	if d.measure != nil {
+		return d.measure.value.peek()
+	}
+	return d._measurement, true
+}
+
+func (d *digit[V, M]) addFirst(measurer Measurer[V, M], item elem[V, M]) *digit[V, M] {
+	result := &digit[V, M]{size: d.size + 1}
+	result.items[0] = item
+	copy(result.items[1:], d.elems())
+	m, ok := d.knownMeasure()
+	if !ok || !item.measured() {
+		return newDigit(measurer, result.elems()...)
+	}
+	result._measurement = measurer.Sum(item.measure(measurer), m)
+	return result
 }
 
-func (d *digit) removeLast() *digit {
-	return d.slice(0, len(d.items)-1)
+func (d *digit[V, M]) addLast(measurer Measurer[V, M], item elem[V, M]) *digit[V, M] {
+	result := &digit[V, M]{size: d.size + 1}
+	copy(result.items[:], d.elems())
+	result.items[d.size] = item
+	m, ok := d.knownMeasure()
+	if !ok || !item.measured() {
+		return newDigit(measurer, result.elems()...)
+	}
+	result._measurement = measurer.Sum(m, item.measure(measurer))
+	return result
 }
 
-func (d *digit) slice(start int, end int) *digit {
-	return newDigit(d._measurement.measurer, d.items[start:end])
+func (d *digit[V, M]) removeFirst(measurer Measurer[V, M]) *digit[V, M] {
+	return d.slice(measurer, 1, d.size)
 }
 
-func (d *digit) peekFirst() any {
+func (d *digit[V, M]) removeLast(measurer Measurer[V, M]) *digit[V, M] {
+	return d.slice(measurer, 0, d.size-1)
+}
+
+func (d *digit[V, M]) slice(measurer Measurer[V, M], start int, end int) *digit[V, M] {
+	return newDigit(measurer, d.items[start:end]...)
+}
+
+func (d *digit[V, M]) peekFirst() elem[V, M] {
 	return d.items[0]
 }
 
-func (d *digit) peekLast() any {
-	return d.items[len(d.items)-1]
+func (d *digit[V, M]) peekLast() elem[V, M] {
+	return d.items[d.size-1]
 }
 
 // Split the digit into 3 parts, in which the left part is the elements
 // that does not satisfy the predicate, the middle part is the first
 // element that satisfies the predicate and the last part is the rest
 // elements.
-func (d *digit) dsplit(predicate predicate, initial any) ([]any, any, []any) {
-	if len(d.items) == 1 {
-		return []any{}, d.items[0], []any{}
-	}
-	m := initial
-	i := 0
-	var item any
-	meas := d._measurement.measurer
-	for i, item = range d.items {
-		m = meas.Sum(m, meas.Measure(item))
-		if predicate(m) {
-			break
-		}
-	}
-	return d.items[:i], item, d.items[i+1:]
+func (d *digit[V, M]) dsplit(measurer Measurer[V, M], predicate Predicate[M], initial M) ([]elem[V, M], elem[V, M], []elem[V, M]) {
+	return splitElems(measurer, d.elems(), predicate, initial)
 }
 
-func (d *digit) Each(f iterFunc) bool {
-	for _, item := range d.items {
+func (d *digit[V, M]) Each(f IterFunc[V]) bool {
+	for _, item := range d.elems() {
 		if !iterateEach(item, f) {
 			return false
 		}
@@ -94,8 +129,8 @@ func (d *digit) Each(f iterFunc) bool {
 	return true
 }
 
-func (d *digit) EachReverse(f iterFunc) bool {
-	for i := len(d.items); i > 0; {
+func (d *digit[V, M]) EachReverse(f IterFunc[V]) bool {
+	for i := d.size; i > 0; {
 		i--
 		if !iterateEachReverse(d.items[i], f) {
 			return false
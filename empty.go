@@ -6,69 +6,89 @@ import (
 )
 
 // An empty finger-tree.
-type emptyTree struct {
-	_measurement measurement
+type emptyTree[V, M any] struct {
+	measurer     Measurer[V, M]
+	_measurement M
 }
 
-func newEmptyTree(measurer measurer) fingerTree {
-	return &emptyTree{measurement{measurer, measurer.Identity()}}
+func newEmptyTree[V, M any](measurer Measurer[V, M]) fingerTree[V, M] {
+	return &emptyTree[V, M]{measurer, measurer.Identity()}
 }
 
-func (e *emptyTree) String() string {
+func (e *emptyTree[V, M]) String() string {
 	return "emptyTree{}"
 }
 
-func (e *emptyTree) Dump(w io.Writer, level int) {}
+func (e *emptyTree[V, M]) Dump(w io.Writer, level int) {}
 
-func (e *emptyTree) measurement() measurement {
+func (e *emptyTree[V, M]) measurement() M {
 	return e._measurement
 }
 
-func (e *emptyTree) AddFirst(value any) fingerTree {
-	return newSingleTree(measurerFor(e), value)
+func (e *emptyTree[V, M]) getMeasurer() Measurer[V, M] {
+	return e.measurer
 }
 
-func (e *emptyTree) AddLast(value any) fingerTree {
-	return newSingleTree(measurerFor(e), value)
+func (e *emptyTree[V, M]) AddFirst(item elem[V, M]) fingerTree[V, M] {
+	return newSingleTree(e.measurer, item)
 }
 
-func (e *emptyTree) RemoveFirst() fingerTree {
+func (e *emptyTree[V, M]) AddLast(item elem[V, M]) fingerTree[V, M] {
+	return newSingleTree(e.measurer, item)
+}
+
+func (e *emptyTree[V, M]) RemoveFirst() fingerTree[V, M] {
 	panic(fmt.Errorf("%w: cannot call RemoveFirst", ErrEmptyTree))
 }
 
-func (e *emptyTree) RemoveLast() fingerTree {
+func (e *emptyTree[V, M]) RemoveLast() fingerTree[V, M] {
 	panic(fmt.Errorf("%w: cannot call RemoveLast", ErrEmptyTree))
 }
 
-func (e *emptyTree) PeekFirst() any {
+func (e *emptyTree[V, M]) PeekFirst() elem[V, M] {
 	panic(fmt.Errorf("%w: cannot call PeekFirst", ErrEmptyTree))
 }
 
-func (e *emptyTree) PeekLast() any {
+func (e *emptyTree[V, M]) PeekLast() elem[V, M] {
 	panic(fmt.Errorf("%w: cannot call PeekLast", ErrEmptyTree))
 }
 
-func (e *emptyTree) Concat(other fingerTree) fingerTree {
+func (e *emptyTree[V, M]) Concat(other fingerTree[V, M]) fingerTree[V, M] {
 	return other
 }
 
-func (e *emptyTree) Split(pred predicate) (fingerTree, fingerTree) {
+func (e *emptyTree[V, M]) Split(pred Predicate[M]) (fingerTree[V, M], fingerTree[V, M]) {
 	return e, e
 }
 
 // never called but required for the interface
-func (e *emptyTree) splitTree(pred predicate, initial any) (fingerTree, any, fingerTree) {
-	return e, nil, e
+func (e *emptyTree[V, M]) splitTree(pred Predicate[M], initial M) (fingerTree[V, M], elem[V, M], fingerTree[V, M]) {
+	return e, elem[V, M]{}, e
+}
+
+// never called but required for the interface
+func (e *emptyTree[V, M]) lookup(pred Predicate[M], initial M) (M, elem[V, M]) {
+	return initial, elem[V, M]{}
+}
+
+// never called but required for the interface
+func (e *emptyTree[V, M]) splitTreeRight(pred Predicate[M], initial M) (fingerTree[V, M], elem[V, M], fingerTree[V, M]) {
+	return e, elem[V, M]{}, e
+}
+
+// never called but required for the interface
+func (e *emptyTree[V, M]) lookupRight(pred Predicate[M], initial M) (M, elem[V, M]) {
+	return initial, elem[V, M]{}
 }
 
-func (d *emptyTree) ToSlice() []any {
-	return []any{}
+func (d *emptyTree[V, M]) ToSlice() []V {
+	return []V{}
 }
 
-func (d *emptyTree) Each(f iterFunc) bool {
+func (d *emptyTree[V, M]) Each(f IterFunc[V]) bool {
 	return true
 }
 
-func (d *emptyTree) EachReverse(f iterFunc) bool {
+func (d *emptyTree[V, M]) EachReverse(f IterFunc[V]) bool {
 	return true
 }
@@ -0,0 +1,123 @@
+package lazyfingertree
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// The Try methods are versions of the FingerTree methods that return errors
+// instead of panicking.
+
+// Run f, turning a finger tree panic into an error. Other panics are not recovered.
+func try[T any](f func() T) (result T, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			if e, ok := r.(error); ok && errors.Is(e, ErrFingerTree) {
+				err = e
+				return
+			}
+			panic(r)
+		}
+	}()
+	return f(), nil
+}
+
+// Return whether two measurers can be used in the same tree. Only their
+// dynamic types are compared, measurers of the same type are assumed to be
+// compatible even when their fields differ, like counters that hold
+// different pointers.
+func compatibleMeasurers[V, M any](a, b Measurer[V, M]) bool {
+	return reflect.TypeOf(a) == reflect.TypeOf(b)
+}
+
+func (t FingerTree[MS, V, M]) check(op string) error {
+	if t.f == nil {
+		return fmt.Errorf("%w, uninitialized tree: cannot call %s", ErrBadValue, op)
+	}
+	return nil
+}
+
+func (t FingerTree[MS, V, M]) checkNotEmpty(op string) error {
+	if err := t.check(op); err != nil {
+		return err
+	} else if isEmpty(t.f) {
+		return fmt.Errorf("%w: cannot call %s", ErrEmptyTree, op)
+	}
+	return nil
+}
+
+// Return the first value in the tree or ErrEmptyTree if there isn't one.
+func (t FingerTree[MS, V, M]) TryPeekFirst() (V, error) {
+	if err := t.checkNotEmpty("PeekFirst"); err != nil {
+		return null[V](), err
+	}
+	return try(t.PeekFirst)
+}
+
+// Return the last value in the tree or ErrEmptyTree if there isn't one.
+func (t FingerTree[MS, V, M]) TryPeekLast() (V, error) {
+	if err := t.checkNotEmpty("PeekLast"); err != nil {
+		return null[V](), err
+	}
+	return try(t.PeekLast)
+}
+
+// Remove the first value in the tree or return ErrEmptyTree if there isn't one.
+func (t FingerTree[MS, V, M]) TryRemoveFirst() (FingerTree[MS, V, M], error) {
+	if err := t.checkNotEmpty("RemoveFirst"); err != nil {
+		return t, err
+	}
+	return try(t.RemoveFirst)
+}
+
+// Remove the last value in the tree or return ErrEmptyTree if there isn't one.
+func (t FingerTree[MS, V, M]) TryRemoveLast() (FingerTree[MS, V, M], error) {
+	if err := t.checkNotEmpty("RemoveLast"); err != nil {
+		return t, err
+	}
+	return try(t.RemoveLast)
+}
+
+// Join two finger trees together or return ErrBadMeasurer if their measurers
+// have different types.
+func (t FingerTree[MS, V, M]) TryConcat(other FingerTree[MS, V, M]) (FingerTree[MS, V, M], error) {
+	if err := t.check("Concat"); err != nil {
+		return t, err
+	} else if err := other.check("Concat"); err != nil {
+		return t, err
+	} else if !compatibleMeasurers(measurerFor(t.f), measurerFor(other.f)) {
+		return t, fmt.Errorf("%w: cannot concatenate trees with different measurers", ErrBadMeasurer)
+	}
+	return try(func() FingerTree[MS, V, M] { return t.Concat(other) })
+}
+
+// Split the tree, returning an error instead of panicking. See [Split].
+func (t FingerTree[MS, V, M]) TrySplit(pred Predicate[M]) (FingerTree[MS, V, M], FingerTree[MS, V, M], error) {
+	if err := t.check("Split"); err != nil {
+		return t, t, err
+	}
+	var right FingerTree[MS, V, M]
+	left, err := try(func() FingerTree[MS, V, M] {
+		var left FingerTree[MS, V, M]
+		left, right = t.Split(pred)
+		return left
+	})
+	return left, right, err
+}
+
+// Return the measure of all the tree's values, returning an error instead of panicking.
+func (t FingerTree[MS, V, M]) TryMeasure() (M, error) {
+	if err := t.check("Measure"); err != nil {
+		return null[M](), err
+	}
+	return try(t.Measure)
+}
+
+// Return a slice of the tree's values, returning an error instead of panicking.
+func (t FingerTree[MS, V, M]) TryToSlice() ([]V, error) {
+	if err := t.check("ToSlice"); err != nil {
+		return nil, err
+	}
+	return try(t.ToSlice)
+}
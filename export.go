@@ -0,0 +1,227 @@
+package lazyfingertree
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// The exporters show the internal structure of a tree for debugging. Unlike
+// Dump, they do not force delayed trees or compute deep tree measures, so
+// exporting a tree does not change it. Delayed trees that have not been
+// forced and nodes whose children have not been built are shown without their
+// contents and measures that have not been computed are left out.
+
+// An ExportOption limits how much of a tree WriteDOT and WriteStructureJSON
+// show
+type ExportOption func(*exportOptions)
+
+type exportOptions struct {
+	maxDepth  int
+	maxLeaves int
+}
+
+// Show at most depth levels of the structure, counting the tree as level 1.
+// Anything deeper is replaced by an elided marker.
+func MaxDepth(depth int) ExportOption {
+	return func(o *exportOptions) {
+		o.maxDepth = depth
+	}
+}
+
+// Show at most count values. The rest of each part that holds more values is
+// replaced by an elided marker.
+func MaxLeaves(count int) ExportOption {
+	return func(o *exportOptions) {
+		o.maxLeaves = count
+	}
+}
+
+// One part of an exported tree
+type structure struct {
+	// empty, single, deep, delayed, digit, node, value, or elided. A delayed
+	// tree that has been forced is shown as the tree it produced. A node whose
+	// children have not been built is marked delayed.
+	Kind     string       `json:"kind"`
+	Role     string       `json:"role,omitempty"`
+	Delayed  bool         `json:"delayed,omitempty"`
+	Reversed bool         `json:"reversed,omitempty"`
+	Measure  any          `json:"measure,omitempty"`
+	Value    any          `json:"value,omitempty"`
+	Children []*structure `json:"children,omitempty"`
+}
+
+type exporter[V, M any] struct {
+	exportOptions
+	leaves int
+}
+
+func (t FingerTree[MS, V, M]) structure(options []ExportOption) *structure {
+	e := &exporter[V, M]{}
+	for _, opt := range options {
+		opt(&e.exportOptions)
+	}
+	if t.f == nil {
+		return &structure{Kind: "empty"}
+	}
+	s := e.tree(t.f, 1)
+	s.Reversed = t.reversed
+	return s
+}
+
+func (e *exporter[V, M]) tooDeep(depth int) bool {
+	return e.maxDepth > 0 && depth > e.maxDepth
+}
+
+func (e *exporter[V, M]) tooManyLeaves() bool {
+	return e.maxLeaves > 0 && e.leaves >= e.maxLeaves
+}
+
+func (e *exporter[V, M]) tree(t fingerTree[V, M], depth int) *structure {
+	wasDelayed := false
+	if d, ok := t.(*delayed[V, M]); ok {
+		forced, done := d.delayedTree.peek()
+		if !done {
+			return &structure{Kind: "delayed"}
+		}
+		t, wasDelayed = forced, true
+	}
+	var s *structure
+	switch t := t.(type) {
+	case *emptyTree[V, M]:
+		s = &structure{Kind: "empty", Measure: t._measurement}
+	case *singleTree[V, M]:
+		s = &structure{Kind: "single"}
+		if m, ok := t.knownMeasure(); ok {
+			s.Measure = m
+		}
+		s.Children = e.elems([]elem[V, M]{t.value}, depth+1)
+	case *deepTree[V, M]:
+		s = &structure{Kind: "deep"}
+		if m, ok := t._measurement.peek(); ok {
+			s.Measure = m
+		}
+		if e.tooDeep(depth + 1) {
+			s.Children = []*structure{{Kind: "elided"}}
+		} else {
+			left := e.digit(t.left, depth+1)
+			left.Role = "left"
+			mid := e.tree(t.mid, depth+1)
+			mid.Role = "mid"
+			right := e.digit(t.right, depth+1)
+			right.Role = "right"
+			s.Children = []*structure{left, mid, right}
+		}
+	default:
+		s = &structure{Kind: fmt.Sprintf("%T", t)}
+	}
+	s.Delayed = wasDelayed
+	return s
+}
+
+func (e *exporter[V, M]) digit(d *digit[V, M], depth int) *structure {
+	s := &structure{Kind: "digit", Children: e.elems(d.elems(), depth+1)}
+	if m, ok := d.knownMeasure(); ok {
+		s.Measure = m
+	}
+	return s
+}
+
+func (e *exporter[V, M]) elems(items []elem[V, M], depth int) []*structure {
+	if e.tooDeep(depth) {
+		return []*structure{{Kind: "elided"}}
+	}
+	result := make([]*structure, 0, len(items))
+	for _, item := range items {
+		if e.tooManyLeaves() {
+			return append(result, &structure{Kind: "elided"})
+		} else if item.node == nil {
+			e.leaves++
+			result = append(result, &structure{Kind: "value", Value: item.value})
+		} else {
+			n := item.node
+			result = append(result, &structure{Kind: "node", Measure: n._measurement, Children: e.elems(n.elems(), depth+1)})
+		}
+	}
+	return result
+}
+
+// Write the tree's internal structure as JSON. Each part is an object with a
+// kind, its cached measure if it has one, and its children. Values and
+// measures are encoded with encoding/json.
+func (t FingerTree[MS, V, M]) WriteStructureJSON(w io.Writer, options ...ExportOption) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(t.structure(options))
+}
+
+// Write the tree's internal structure as a Graphviz DOT graph. Delayed trees
+// are drawn dashed, with no contents if they have not been forced.
+func (t FingerTree[MS, V, M]) WriteDOT(w io.Writer, options ...ExportOption) error {
+	out := bufio.NewWriter(w)
+	fmt.Fprintln(out, "digraph fingertree {")
+	fmt.Fprintln(out, `  node [fontname="monospace"];`)
+	id := 0
+	var write func(s *structure) int
+	write = func(s *structure) int {
+		me := id
+		id++
+		label, attrs := dotLabel(s)
+		fmt.Fprintf(out, "  n%d [label=\"%s\"%s];\n", me, dotEscape(label), attrs)
+		for _, child := range s.Children {
+			childID := write(child)
+			if child.Role != "" {
+				fmt.Fprintf(out, "  n%d -> n%d [label=\"%s\"];\n", me, childID, child.Role)
+			} else {
+				fmt.Fprintf(out, "  n%d -> n%d;\n", me, childID)
+			}
+		}
+		return me
+	}
+	write(t.structure(options))
+	fmt.Fprintln(out, "}")
+	return out.Flush()
+}
+
+func dotLabel(s *structure) (string, string) {
+	var label strings.Builder
+	attrs := ""
+	switch s.Kind {
+	case "value":
+		return Brief(s.Value), ", shape=plaintext"
+	case "elided":
+		return "...", ", shape=plaintext"
+	case "delayed":
+		return "delayed\n(not forced)", ", shape=box, style=dashed"
+	case "digit":
+		attrs = ", shape=box, style=rounded"
+	case "node":
+		attrs = ", shape=ellipse"
+		if s.Delayed {
+			attrs += ", style=dashed"
+		}
+	default:
+		attrs = ", shape=box"
+		if s.Delayed {
+			attrs += ", style=dashed"
+		}
+	}
+	label.WriteString(s.Kind)
+	if s.Delayed {
+		label.WriteString(" (delayed)")
+	}
+	if s.Reversed {
+		label.WriteString(" (reversed)")
+	}
+	if s.Measure != nil {
+		label.WriteString("\n")
+		label.WriteString(Brief(s.Measure))
+	}
+	return label.String(), attrs
+}
+
+func dotEscape(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s)
+}
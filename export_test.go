@@ -0,0 +1,57 @@
+package lazyfingertree
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func countKind(s *structure, kind string) int {
+	count := 0
+	if s.Kind == kind {
+		count++
+	}
+	for _, child := range s.Children {
+		count += countKind(child, kind)
+	}
+	return count
+}
+
+func TestExportDoesNotForce(t *testing.T) {
+	left, right := newTree(ints(100)...).Split(func(w int) bool { return w > 40 })
+	tree := left.Concat(right).RemoveFirst().RemoveLast()
+	unforced := countKind(tree.structure(nil), "delayed")
+	failIfNot(t, unforced > 0)
+	var dot, js bytes.Buffer
+	failIfErrNow(t, tree.WriteDOT(&dot))
+	failIfErrNow(t, tree.WriteStructureJSON(&js))
+	failIfNot(t, countKind(tree.structure(nil), "delayed") == unforced)
+	failIfNot(t, strings.HasPrefix(dot.String(), "digraph fingertree {"))
+	failIfNot(t, strings.Contains(dot.String(), "not forced"))
+	var decoded map[string]any
+	failIfErrNow(t, json.Unmarshal(js.Bytes(), &decoded))
+	failIfNot(t, decoded["kind"] == "deep")
+	// forcing the tree shows everything
+	failIfNot(t, same(tree.ToSlice(), ints(100)[1:99]))
+	full := tree.structure(nil)
+	failIfNot(t, countKind(full, "delayed") == 0)
+	failIfNot(t, countKind(full, "value") == 98)
+}
+
+func TestExportOptions(t *testing.T) {
+	tree := newTree(ints(1000)...)
+	failIfNot(t, countKind(tree.structure([]ExportOption{MaxLeaves(10)}), "value") == 10)
+	shallow := tree.structure([]ExportOption{MaxDepth(2)})
+	failIfNot(t, len(shallow.Children) == 3)
+	for _, child := range shallow.Children {
+		failIfNot(t, len(child.Children) == 1 && child.Children[0].Kind == "elided")
+	}
+	failIfNot(t, countKind(tree.structure([]ExportOption{MaxDepth(1)}), "elided") == 1)
+	var js bytes.Buffer
+	failIfErrNow(t, FingerTree[width[int, int], int, int]{}.WriteStructureJSON(&js))
+	failIfNot(t, strings.Contains(js.String(), `"kind": "empty"`))
+	js.Reset()
+	failIfErrNow(t, newTree(1, 2, 3).Reverse().WriteStructureJSON(&js))
+	failIfNot(t, strings.Contains(js.String(), `"reversed": true`))
+}
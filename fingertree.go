@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"iter"
 	"strings"
 )
 
@@ -17,10 +18,6 @@ var ErrBadMeasurer = fmt.Errorf("%w, bad measurer", ErrFingerTree)
 
 var ErrExpectedNode = fmt.Errorf("%w, expected a node", ErrFingerTree)
 
-type predicate func(measure any) bool
-
-type iterFunc func(value any) bool
-
 type HasBrief interface {
 	Brief() string
 }
@@ -43,115 +40,440 @@ func Brief(v any) string {
 	return strings.ReplaceAll(fmt.Sprint(v), "\n", " ")
 }
 
-type measurer interface {
-	Identity() any
-	// measuring a value could technically produce an error but really should not
-	// make sure to validate inputs or to use a panic if you need error support
-	Measure(value any) any
-	Sum(a any, b any) any
+// An elem is an item in a digit, node, or single tree. In the top level of a
+// tree it holds a value and in a mid tree it holds a node.
+type elem[V, M any] struct {
+	node  *node[V, M]
+	value V
+}
+
+func leaf[V, M any](value V) elem[V, M] {
+	return elem[V, M]{value: value}
+}
+
+func (e elem[V, M]) measure(measurer Measurer[V, M]) M {
+	if e.node != nil {
+		return e.node.measurement()
+	}
+	return measurer.Measure(e.value)
 }
 
-type measurement struct {
-	measurer measurer
-	value    any
+// Return whether the element's measure is known or is a value's measure
+func (e elem[V, M]) measured() bool {
+	if e.node == nil || e.node.pending == nil || e.node.pending.measure == nil {
+		return true
+	}
+	return e.node.pending.measure.value.done.Load()
 }
 
-func newMeasurement(measurer measurer, value any) measurement {
-	return measurement{measurer, measurer.Measure(value)}
+// Return the sum of the items' measures if none of them are nodes whose
+// measures are computed when first used
+func knownSum[V, M any](measurer Measurer[V, M], items []elem[V, M]) (M, bool) {
+	for _, item := range items {
+		if !item.measured() {
+			return null[M](), false
+		}
+	}
+	return sumElems(measurer, measurer.Identity(), items), true
 }
 
-func (m measurement) empty() fingerTree {
-	return newEmptyTree(m.measurer)
+func (e elem[V, M]) asNode() *node[V, M] {
+	if e.node == nil {
+		panic(ErrExpectedNode)
+	}
+	return e.node
 }
 
-// An EmptyTree, singleTree, deepTree, or delayed
-type fingerTree interface {
-	AddFirst(value any) fingerTree
-	AddLast(value any) fingerTree
-	RemoveFirst() fingerTree
-	RemoveLast() fingerTree
-	PeekFirst() any
-	PeekLast() any
-	Concat(other fingerTree) fingerTree
-	Split(predicate predicate) (fingerTree, fingerTree)
-	ToSlice() []any
-	Each(f iterFunc) bool
-	EachReverse(f iterFunc) bool
-	measurement() measurement
-	splitTree(predicate predicate, initial any) (fingerTree, any, fingerTree)
+// Return the node or the value
+func (e elem[V, M]) item() any {
+	if e.node != nil {
+		return e.node
+	}
+	return e.value
+}
+
+func (e elem[V, M]) String() string {
+	return fmt.Sprint(e.item())
+}
+
+// An EmptyTree, singleTree, deepTree, or delayed.
+// Trees at the top level contain values and mid trees contain nodes.
+type fingerTree[V, M any] interface {
+	AddFirst(item elem[V, M]) fingerTree[V, M]
+	AddLast(item elem[V, M]) fingerTree[V, M]
+	RemoveFirst() fingerTree[V, M]
+	RemoveLast() fingerTree[V, M]
+	PeekFirst() elem[V, M]
+	PeekLast() elem[V, M]
+	Concat(other fingerTree[V, M]) fingerTree[V, M]
+	Split(predicate Predicate[M]) (fingerTree[V, M], fingerTree[V, M])
+	ToSlice() []V
+	Each(f IterFunc[V]) bool
+	EachReverse(f IterFunc[V]) bool
+	measurement() M
+	getMeasurer() Measurer[V, M]
+	splitTree(predicate Predicate[M], initial M) (fingerTree[V, M], elem[V, M], fingerTree[V, M])
+	lookup(predicate Predicate[M], initial M) (M, elem[V, M])
+	// Like splitTree and lookup but scanning from the end with suffix measures
+	splitTreeRight(predicate Predicate[M], initial M) (fingerTree[V, M], elem[V, M], fingerTree[V, M])
+	lookupRight(predicate Predicate[M], initial M) (M, elem[V, M])
 	fmt.Stringer
 	Dump(w io.Writer, level int)
 }
 
-func isEmpty(tree fingerTree) bool {
-	_, ok := force(tree).(*emptyTree)
+func isEmpty[V, M any](tree fingerTree[V, M]) bool {
+	_, ok := force(tree).(*emptyTree[V, M])
 	return ok
 }
 
-func isSingle(tree fingerTree) bool {
-	_, ok := tree.(*singleTree)
+func isSingle[V, M any](tree fingerTree[V, M]) bool {
+	_, ok := tree.(*singleTree[V, M])
 	return ok
 }
 
-func measurerFor(tree fingerTree) measurer {
-	return tree.measurement().measurer
+func measurerFor[V, M any](tree fingerTree[V, M]) Measurer[V, M] {
+	return tree.getMeasurer()
 }
 
-func force(tree fingerTree) fingerTree {
-	t, ok := tree.(*delayed)
+func force[V, M any](tree fingerTree[V, M]) fingerTree[V, M] {
+	t, ok := tree.(*delayed[V, M])
 	if !ok {
 		return tree
 	}
 	return t.force()
 }
 
-func empty(tree fingerTree) fingerTree {
-	return newEmptyTree(tree.measurement().measurer)
+// Return the tree's measure if it can be found without forcing a delayed
+// tree, computing and caching the measures of deep trees on the way
+func cheapMeasure[V, M any](tree fingerTree[V, M]) (M, bool) {
+	switch t := tree.(type) {
+	case *delayed[V, M]:
+		if forced, ok := t.delayedTree.peek(); ok {
+			return cheapMeasure(forced)
+		}
+		return null[M](), false
+	case *deepTree[V, M]:
+		if m, ok := t._measurement.peek(); ok {
+			return m, true
+		} else if _, ok := t.left.knownMeasure(); !ok {
+			return m, false
+		} else if _, ok := t.right.knownMeasure(); !ok {
+			return m, false
+		} else if _, ok := cheapMeasure(t.mid); !ok {
+			return m, false
+		}
+	case *singleTree[V, M]:
+		return t.knownMeasure()
+	}
+	return tree.measurement(), true
+}
+
+// Return whether the predicate holds for prefix plus the tree's measure. If it
+// does not, this also returns that sum. Parts of the tree whose measures are
+// not known without forcing delayed trees or measuring lazy nodes are searched
+// instead, so only the part of the tree up to the value where the predicate
+// first holds is forced and measured.
+func holds[V, M any](meas Measurer[V, M], tree fingerTree[V, M], pred Predicate[M], prefix M) (M, bool) {
+	if m, ok := cheapMeasure(tree); ok {
+		m = meas.Sum(prefix, m)
+		return m, pred(m)
+	}
+	switch t := force(tree).(type) {
+	case *singleTree[V, M]:
+		return holdsElem(meas, t.value, pred, prefix)
+	case *deepTree[V, M]:
+		m, ok := holdsDigit(meas, t.left, pred, prefix)
+		if ok {
+			return m, true
+		} else if m, ok = holds(meas, t.mid, pred, m); ok {
+			return m, true
+		}
+		return holdsDigit(meas, t.right, pred, m)
+	}
+	m := meas.Sum(prefix, tree.measurement())
+	return m, pred(m)
+}
+
+// Like holds but adding the tree's measure before a suffix and searching from
+// the end
+func holdsRight[V, M any](meas Measurer[V, M], tree fingerTree[V, M], pred Predicate[M], suffix M) (M, bool) {
+	if m, ok := cheapMeasure(tree); ok {
+		m = meas.Sum(m, suffix)
+		return m, pred(m)
+	}
+	switch t := force(tree).(type) {
+	case *singleTree[V, M]:
+		return holdsElemRight(meas, t.value, pred, suffix)
+	case *deepTree[V, M]:
+		m, ok := holdsDigitRight(meas, t.right, pred, suffix)
+		if ok {
+			return m, true
+		} else if m, ok = holdsRight(meas, t.mid, pred, m); ok {
+			return m, true
+		}
+		return holdsDigitRight(meas, t.left, pred, m)
+	}
+	m := meas.Sum(tree.measurement(), suffix)
+	return m, pred(m)
+}
+
+// Like holds for a digit, searching its items if its measure is not known
+func holdsDigit[V, M any](meas Measurer[V, M], d *digit[V, M], pred Predicate[M], prefix M) (M, bool) {
+	if m, ok := d.knownMeasure(); ok {
+		m = meas.Sum(prefix, m)
+		return m, pred(m)
+	}
+	m, own, ok := probeElems(meas, d.elems(), pred, prefix)
+	if !ok {
+		d.measure.value.get(func() M { return own })
+	}
+	return m, ok
+}
+
+func holdsDigitRight[V, M any](meas Measurer[V, M], d *digit[V, M], pred Predicate[M], suffix M) (M, bool) {
+	if m, ok := d.knownMeasure(); ok {
+		m = meas.Sum(m, suffix)
+		return m, pred(m)
+	}
+	m, own, ok := probeElemsRight(meas, d.elems(), pred, suffix)
+	if !ok {
+		d.measure.value.get(func() M { return own })
+	}
+	return m, ok
+}
+
+// Like holds for an element, searching a node's children if its measure is
+// not known
+func holdsElem[V, M any](meas Measurer[V, M], e elem[V, M], pred Predicate[M], prefix M) (M, bool) {
+	if e.node == nil {
+		m := meas.Sum(prefix, meas.Measure(e.value))
+		return m, pred(m)
+	} else if m, ok := e.node.knownMeasure(); ok {
+		m = meas.Sum(prefix, m)
+		return m, pred(m)
+	}
+	m, own, ok := probeElems(meas, e.node.elems(), pred, prefix)
+	if !ok {
+		e.node.pending.measure.value.get(func() M { return own })
+	}
+	return m, ok
+}
+
+func holdsElemRight[V, M any](meas Measurer[V, M], e elem[V, M], pred Predicate[M], suffix M) (M, bool) {
+	if e.node == nil {
+		m := meas.Sum(meas.Measure(e.value), suffix)
+		return m, pred(m)
+	} else if m, ok := e.node.knownMeasure(); ok {
+		m = meas.Sum(m, suffix)
+		return m, pred(m)
+	}
+	m, own, ok := probeElemsRight(meas, e.node.elems(), pred, suffix)
+	if !ok {
+		e.node.pending.measure.value.get(func() M { return own })
+	}
+	return m, ok
+}
+
+// Search items for the first one where pred holds on prefix plus the measures
+// up to and including it, looking inside nodes whose measures are not known.
+// Returns that sum, or the sum with all of the items, and the measure of all
+// the items if pred never holds. Nodes that are searched to the end keep
+// their measures.
+func probeElems[V, M any](meas Measurer[V, M], items []elem[V, M], pred Predicate[M], prefix M) (sum, own M, ok bool) {
+	sum, own = prefix, meas.Identity()
+	for _, item := range items {
+		var m M
+		if item.node == nil {
+			m = meas.Measure(item.value)
+		} else if known, ok := item.node.knownMeasure(); ok {
+			m = known
+		} else {
+			s, childOwn, found := probeElems(meas, item.node.elems(), pred, sum)
+			if found {
+				return s, own, true
+			}
+			m = item.node.pending.measure.value.get(func() M { return childOwn })
+		}
+		if sum = meas.Sum(sum, m); pred(sum) {
+			return sum, own, true
+		}
+		own = meas.Sum(own, m)
+	}
+	return sum, own, false
 }
 
-func takeUntil(tree fingerTree, f predicate) fingerTree {
+// Like probeElems but searching from the end with suffix measures
+func probeElemsRight[V, M any](meas Measurer[V, M], items []elem[V, M], pred Predicate[M], suffix M) (sum, own M, ok bool) {
+	sum, own = suffix, meas.Identity()
+	for i := len(items) - 1; i >= 0; i-- {
+		item := items[i]
+		var m M
+		if item.node == nil {
+			m = meas.Measure(item.value)
+		} else if known, ok := item.node.knownMeasure(); ok {
+			m = known
+		} else {
+			s, childOwn, found := probeElemsRight(meas, item.node.elems(), pred, sum)
+			if found {
+				return s, own, true
+			}
+			m = item.node.pending.measure.value.get(func() M { return childOwn })
+		}
+		if sum = meas.Sum(m, sum); pred(sum) {
+			return sum, own, true
+		}
+		own = meas.Sum(m, own)
+	}
+	return sum, own, false
+}
+
+func takeUntil[V, M any](tree fingerTree[V, M], f Predicate[M]) fingerTree[V, M] {
 	first, _ := tree.Split(f)
 	return first
 }
 
-func dropUntil(tree fingerTree, f predicate) fingerTree {
+func dropUntil[V, M any](tree fingerTree[V, M], f Predicate[M]) fingerTree[V, M] {
 	_, rest := tree.Split(f)
 	return rest
 }
 
-// Construct a fingertree from an array.
-func fromArray(measurer measurer, values []any) fingerTree {
-	return prependTree(newEmptyTree(measurer), values)
+// Construct a fingertree from an array in linear time.
+func fromArray[V, M any](measurer Measurer[V, M], values []V) fingerTree[V, M] {
+	b := newTreeBuilder(measurer)
+	for _, v := range values {
+		b.add(leaf[V, M](v))
+	}
+	return b.build()
+}
+
+// Construct a fingertree from a sequence in linear time.
+func fromSeq[V, M any](measurer Measurer[V, M], values iter.Seq[V]) fingerTree[V, M] {
+	b := newTreeBuilder(measurer)
+	for v := range values {
+		b.add(leaf[V, M](v))
+	}
+	return b.build()
+}
+
+// Construct a fingertree from elems, which may be values or nodes.
+func fromElems[V, M any](measurer Measurer[V, M], items []elem[V, M]) fingerTree[V, M] {
+	b := newTreeBuilder(measurer)
+	for _, item := range items {
+		b.add(item)
+	}
+	return b.build()
 }
 
 // Prepend an array of elements to the left of a tree.
 // Returns a new tree with the original one unmodified.
-func prependTree[V any](tree fingerTree, values []V) fingerTree {
-	for i := len(values) - 1; i >= 0; i-- {
-		tree = tree.AddFirst(values[i])
+func prependTree[V, M any](tree fingerTree[V, M], items []elem[V, M]) fingerTree[V, M] {
+	for i := len(items) - 1; i >= 0; i-- {
+		tree = tree.AddFirst(items[i])
 	}
 	return tree
 }
 
 // Append an array of elements to the right of a tree.
 // Returns a new tree with the original one unmodified.
-func appendTree[V any](tree fingerTree, values []V) fingerTree {
-	for i := 0; i < len(values); i++ {
-		tree = tree.AddLast(values[i])
+func appendTree[V, M any](tree fingerTree[V, M], items []elem[V, M]) fingerTree[V, M] {
+	for i := 0; i < len(items); i++ {
+		tree = tree.AddLast(items[i])
 	}
 	return tree
 }
 
-func iterateEach(item any, f iterFunc) bool {
-	if n, ok := item.(*node); ok {
-		return n.Each(f)
+// Return the sum of the measures of the items, starting with initial.
+func sumElems[V, M any](measurer Measurer[V, M], initial M, items []elem[V, M]) M {
+	m := initial
+	for _, item := range items {
+		m = measurer.Sum(m, item.measure(measurer))
+	}
+	return m
+}
+
+// Split items into 3 parts, in which the left part is the elements
+// that do not satisfy the predicate, the middle part is the first
+// element that satisfies the predicate and the last part is the rest
+// of the elements. The middle part is the last element if none satisfy it.
+func splitElems[V, M any](measurer Measurer[V, M], items []elem[V, M], predicate Predicate[M], initial M) ([]elem[V, M], elem[V, M], []elem[V, M]) {
+	_, i := findElem(measurer, items, predicate, initial)
+	return items[:i], items[i], items[i+1:]
+}
+
+// Find the first item that satisfies the predicate, returning the measure of
+// the items before it and the item. Returns the last item if none satisfy it.
+func lookupElems[V, M any](measurer Measurer[V, M], items []elem[V, M], predicate Predicate[M], initial M) (M, elem[V, M]) {
+	prefix, i := findElem(measurer, items, predicate, initial)
+	return prefix, items[i]
+}
+
+func findElem[V, M any](measurer Measurer[V, M], items []elem[V, M], predicate Predicate[M], initial M) (M, int) {
+	prefix := initial
+	for i, item := range items {
+		if i == len(items)-1 {
+			return prefix, i
+		}
+		if !item.measured() {
+			m, ok := holdsElem(measurer, item, predicate, prefix)
+			if ok {
+				return prefix, i
+			}
+			prefix = m
+			continue
+		}
+		m := measurer.Sum(prefix, item.measure(measurer))
+		if predicate(m) {
+			return prefix, i
+		}
+		prefix = m
+	}
+	return prefix, 0
+}
+
+// Like splitElems but scanning from the end with suffix measures. The right
+// part is the elements after the middle one.
+func splitElemsRight[V, M any](measurer Measurer[V, M], items []elem[V, M], predicate Predicate[M], initial M) ([]elem[V, M], elem[V, M], []elem[V, M]) {
+	_, i := findElemRight(measurer, items, predicate, initial)
+	return items[:i], items[i], items[i+1:]
+}
+
+// Like lookupElems but scanning from the end, returning the measure of the
+// items after the one it finds
+func lookupElemsRight[V, M any](measurer Measurer[V, M], items []elem[V, M], predicate Predicate[M], initial M) (M, elem[V, M]) {
+	suffix, i := findElemRight(measurer, items, predicate, initial)
+	return suffix, items[i]
+}
+
+func findElemRight[V, M any](measurer Measurer[V, M], items []elem[V, M], predicate Predicate[M], initial M) (M, int) {
+	suffix := initial
+	for i := len(items) - 1; i > 0; i-- {
+		if !items[i].measured() {
+			m, ok := holdsElemRight(measurer, items[i], predicate, suffix)
+			if ok {
+				return suffix, i
+			}
+			suffix = m
+			continue
+		}
+		m := measurer.Sum(items[i].measure(measurer), suffix)
+		if predicate(m) {
+			return suffix, i
+		}
+		suffix = m
+	}
+	return suffix, 0
+}
+
+func iterateEach[V, M any](item elem[V, M], f IterFunc[V]) bool {
+	if item.node != nil {
+		return item.node.Each(f)
 	}
-	return f(item)
+	return f(item.value)
 }
 
-func iterateEachReverse(item any, f iterFunc) bool {
-	if n, ok := item.(*node); ok {
-		return n.EachReverse(f)
+func iterateEachReverse[V, M any](item elem[V, M], f IterFunc[V]) bool {
+	if item.node != nil {
+		return item.node.EachReverse(f)
 	}
-	return f(item)
+	return f(item.value)
 }
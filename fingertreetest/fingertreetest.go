@@ -0,0 +1,281 @@
+// Package fingertreetest helps test measurers and the trees that use them.
+//
+// Split and the other searches only give correct answers when the measurer is
+// a monoid: Identity must add nothing and Sum must be associative. A measurer
+// that breaks these laws does not cause errors, it causes wrong answers.
+// [CheckMeasurer] tests the laws on generated values and reports a
+// counterexample. [Run] applies operations to a tree and to a slice that
+// models it and reports the first place they differ. [Fuzz] plugs Run into Go's
+// native fuzzing so you can fuzz trees of your own measurer:
+//
+//	func FuzzLines(f *testing.F) {
+//		fingertreetest.Fuzz(f, lineMeasurer{}, func(r *rand.Rand) string {
+//			return strings.Repeat("x\n", r.IntN(3))
+//		})
+//	}
+package fingertreetest
+
+import (
+	"fmt"
+	"hash/fnv"
+	"math/rand/v2"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	ft "github.com/leisure-tools/lazyfingertree"
+	"github.com/leisure-tools/lazyfingertree/measures"
+)
+
+var ErrMismatch = fmt.Errorf("%w, tree does not match model", ft.ErrFingerTree)
+
+// The number of random cases CheckMeasurer tries
+const trials = 500
+
+// A Counterexample shows values for which a measurer breaks a law. Got and
+// Want are the measures that should have been equal.
+type Counterexample[V, M any] struct {
+	Law    string
+	Values []V
+	Got    M
+	Want   M
+}
+
+func (c *Counterexample[V, M]) Error() string {
+	return fmt.Sprintf("%s: measurer breaks %s for values %v: got %v, want %v", ft.ErrBadMeasurer, c.Law, c.Values, c.Got, c.Want)
+}
+
+func (c *Counterexample[V, M]) Unwrap() error {
+	return ft.ErrBadMeasurer
+}
+
+func deepEqual[T any](a, b T) bool {
+	return reflect.DeepEqual(a, b)
+}
+
+// Check that the measurer is a monoid on values from gen, comparing measures
+// with reflect.DeepEqual. Returns a [*Counterexample] if it is not.
+func CheckMeasurer[MS ft.Measurer[V, M], V, M any](ms MS, gen func(*rand.Rand) V) error {
+	return CheckMeasurerFunc(ms, gen, deepEqual[M])
+}
+
+// Check that the measurer is a monoid on values from gen, comparing measures
+// with eq. Returns a [*Counterexample] if it is not. This also checks that
+// measuring is repeatable and that Sum does not change its arguments.
+func CheckMeasurerFunc[MS ft.Measurer[V, M], V, M any](ms MS, gen func(*rand.Rand) V, eq func(a, b M) bool) error {
+	r := rand.New(rand.NewPCG(1, 2))
+	values := func() []V {
+		result := make([]V, r.IntN(5))
+		for i := range result {
+			result[i] = gen(r)
+		}
+		return result
+	}
+	sum := func(values []V) M {
+		result := ms.Identity()
+		for _, v := range values {
+			result = ms.Sum(result, ms.Measure(v))
+		}
+		return result
+	}
+	fail := func(law string, got, want M, values ...[]V) error {
+		return &Counterexample[V, M]{law, slices.Concat(values...), got, want}
+	}
+	for range trials {
+		a, b, c := values(), values(), values()
+		ma, mb, mc := sum(a), sum(b), sum(c)
+		if again := sum(a); !eq(again, ma) {
+			return fail("repeatable measures", again, ma, a)
+		} else if got := ms.Sum(ms.Identity(), ma); !eq(got, ma) {
+			return fail("left identity", got, ma, a)
+		} else if got := ms.Sum(ma, ms.Identity()); !eq(got, ma) {
+			return fail("right identity", got, ma, a)
+		}
+		left := ms.Sum(ms.Sum(ma, mb), mc)
+		if right := ms.Sum(ma, ms.Sum(mb, mc)); !eq(left, right) {
+			return fail("associativity", left, right, a, b, c)
+		}
+		if again := sum(a); !eq(again, ma) {
+			return fail("unchanged arguments to Sum", ma, again, a)
+		} else if again := sum(b); !eq(again, mb) {
+			return fail("unchanged arguments to Sum", mb, again, b)
+		} else if again := sum(c); !eq(again, mc) {
+			return fail("unchanged arguments to Sum", mc, again, c)
+		}
+	}
+	return nil
+}
+
+// Decode operations from data, apply them to a tree and a slice that models
+// it, and return an error describing the first difference or the first
+// problem [ft.FingerTree.Validate] finds. Values and measures are compared
+// with reflect.DeepEqual, values that are not equal to themselves, like NaN,
+// match each other. Values come from gen with a random source seeded from
+// data, so the same data always runs the same operations.
+func Run[MS ft.Measurer[V, M], V, M any](ms MS, gen func(*rand.Rand) V, data []byte) error {
+	return RunFunc(ms, gen, data, deepEqual[M])
+}
+
+// Like [Run] but comparing measures with eq, also when validating the tree
+func RunFunc[MS ft.Measurer[V, M], V, M any](ms MS, gen func(*rand.Rand) V, data []byte, eq func(a, b M) bool) error {
+	m := &model[V, M]{
+		measurer: measures.Pair[V, int, M](measures.Count[V]{}, ms),
+		eq:       eq,
+	}
+	return m.run(gen, data)
+}
+
+// Fuzz trees of the measurer with [Run], adding a few seed inputs
+func Fuzz[MS ft.Measurer[V, M], V, M any](f *testing.F, ms MS, gen func(*rand.Rand) V) {
+	FuzzFunc(f, ms, gen, deepEqual[M])
+}
+
+// Like [Fuzz] but comparing measures with eq
+func FuzzFunc[MS ft.Measurer[V, M], V, M any](f *testing.F, ms MS, gen func(*rand.Rand) V, eq func(a, b M) bool) {
+	f.Add([]byte{})
+	f.Add([]byte{1, 1, 1, 0, 0, 4, 2})
+	f.Add([]byte("split and concat: \x05\x07\x0b\x05\x04\x03\x0a\x10\x16\x11\x02\x03"))
+	f.Fuzz(func(t *testing.T, data []byte) {
+		if err := RunFunc(ms, gen, data, eq); err != nil {
+			t.Fatal(err)
+		}
+	})
+}
+
+// Trees in the model also count their values so splits can find positions
+type countedTree[V, M any] = ft.FingerTree[measures.PairMeasurer[V, int, M], V, measures.PairMeasure[int, M]]
+
+type model[V, M any] struct {
+	measurer measures.PairMeasurer[V, int, M]
+	eq       func(a, b M) bool
+	tree     countedTree[V, M]
+	values   []V
+	ops      []string
+}
+
+func (m *model[V, M]) pairEq(a, b measures.PairMeasure[int, M]) bool {
+	return a.A == b.A && m.eq(a.B, b.B)
+}
+
+func (m *model[V, M]) fromValues(values []V) countedTree[V, M] {
+	return ft.FromArray(m.measurer, values)
+}
+
+func (m *model[V, M]) run(gen func(*rand.Rand) V, data []byte) error {
+	h := fnv.New64a()
+	h.Write(data)
+	r := rand.New(rand.NewPCG(h.Sum64(), uint64(len(data))))
+	m.tree = m.fromValues(nil)
+	for i := 0; i < len(data); i++ {
+		op := data[i]
+		arg := 0
+		if i+1 < len(data) {
+			arg = int(data[i+1])
+		}
+		switch op % 6 {
+		case 0:
+			v := gen(r)
+			m.tree = m.tree.AddFirst(v)
+			m.values = slices.Insert(m.values, 0, v)
+			m.ops = append(m.ops, fmt.Sprintf("AddFirst(%v)", v))
+		case 1:
+			v := gen(r)
+			m.tree = m.tree.AddLast(v)
+			m.values = append(m.values, v)
+			m.ops = append(m.ops, fmt.Sprintf("AddLast(%v)", v))
+		case 2:
+			if len(m.values) == 0 {
+				continue
+			}
+			m.tree = m.tree.RemoveFirst()
+			m.values = m.values[1:]
+			m.ops = append(m.ops, "RemoveFirst()")
+		case 3:
+			if len(m.values) == 0 {
+				continue
+			}
+			m.tree = m.tree.RemoveLast()
+			m.values = m.values[:len(m.values)-1]
+			m.ops = append(m.ops, "RemoveLast()")
+		case 4:
+			i++
+			pos := arg % (len(m.values) + 1)
+			left, right := m.tree.Split(measures.OnA[M](measures.AtIndex(pos)))
+			if err := m.check(left, m.values[:pos], fmt.Sprintf("left of Split(%d)", pos)); err != nil {
+				return err
+			} else if err := m.check(right, m.values[pos:], fmt.Sprintf("right of Split(%d)", pos)); err != nil {
+				return err
+			}
+			// keep one side or join them back together
+			switch op / 6 % 3 {
+			case 0:
+				m.tree = left
+				m.values = m.values[:pos]
+				m.ops = append(m.ops, fmt.Sprintf("TakeUntil(%d)", pos))
+			case 1:
+				m.tree = right
+				m.values = m.values[pos:]
+				m.ops = append(m.ops, fmt.Sprintf("DropUntil(%d)", pos))
+			default:
+				m.tree = left.Concat(right)
+				m.ops = append(m.ops, fmt.Sprintf("Split(%d) and Concat", pos))
+			}
+		case 5:
+			i++
+			values := make([]V, arg%8)
+			for j := range values {
+				values[j] = gen(r)
+			}
+			if op/6%2 == 0 {
+				m.tree = m.tree.Concat(m.fromValues(values))
+				m.values = append(m.values, values...)
+				m.ops = append(m.ops, fmt.Sprintf("Concat(%v)", values))
+			} else {
+				m.tree = m.fromValues(values).Concat(m.tree)
+				m.values = append(slices.Clone(values), m.values...)
+				m.ops = append(m.ops, fmt.Sprintf("Concat(%v, tree)", values))
+			}
+		}
+		if err := m.check(m.tree, m.values, "tree"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Values that are not equal to themselves, like NaN, match each other
+func sameValue[V any](a, b V) bool {
+	return reflect.DeepEqual(a, b) || !reflect.DeepEqual(a, a) && !reflect.DeepEqual(b, b)
+}
+
+func (m *model[V, M]) check(tree countedTree[V, M], values []V, what string) error {
+	fail := func(format string, args ...any) error {
+		return fmt.Errorf("%w: %s after %s: %s", ErrMismatch, what, strings.Join(m.ops, ", "), fmt.Sprintf(format, args...))
+	}
+	if err := tree.ValidateFunc(m.pairEq); err != nil {
+		return fmt.Errorf("%s after %s: %w", what, strings.Join(m.ops, ", "), err)
+	}
+	if got := tree.ToSlice(); !slices.EqualFunc(got, values, sameValue) {
+		return fail("values are %v, want %v", got, values)
+	}
+	measure := tree.Measure()
+	if measure.A != len(values) {
+		return fail("count is %d, want %d", measure.A, len(values))
+	}
+	want := m.measurer.Identity()
+	for _, v := range values {
+		want = m.measurer.Sum(want, m.measurer.Measure(v))
+	}
+	if !m.eq(measure.B, want.B) {
+		return fail("measure is %v, want %v", measure.B, want.B)
+	}
+	if len(values) > 0 {
+		if first := tree.PeekFirst(); !sameValue(first, values[0]) {
+			return fail("first value is %v, want %v", first, values[0])
+		} else if last := tree.PeekLast(); !sameValue(last, values[len(values)-1]) {
+			return fail("last value is %v, want %v", last, values[len(values)-1])
+		}
+	}
+	return nil
+}
@@ -0,0 +1,119 @@
+package fingertreetest
+
+import (
+	"errors"
+	"math"
+	"math/rand/v2"
+	"testing"
+
+	ft "github.com/leisure-tools/lazyfingertree"
+	"github.com/leisure-tools/lazyfingertree/measures"
+)
+
+func smallInt(r *rand.Rand) int {
+	return r.IntN(100)
+}
+
+// Weighting the left measure has an identity but is not associative
+type weighted struct{}
+
+func (weighted) Identity() int     { return 0 }
+func (weighted) Measure(v int) int { return v + 1 }
+func (weighted) Sum(a, b int) int {
+	if a == 0 {
+		return b
+	} else if b == 0 {
+		return a
+	}
+	return 2*a + b
+}
+
+// Counting the joins as well as the values is associative but has no identity
+type joins struct{}
+
+func (joins) Identity() int     { return 0 }
+func (joins) Measure(v int) int { return 1 }
+func (joins) Sum(a, b int) int  { return a + b + 1 }
+
+// Adds to its left argument instead of making a new set
+type mutating struct{}
+
+func (mutating) Identity() map[int]bool     { return map[int]bool{} }
+func (mutating) Measure(v int) map[int]bool { return map[int]bool{v: true} }
+func (mutating) Sum(a, b map[int]bool) map[int]bool {
+	for k := range b {
+		a[k] = true
+	}
+	return a
+}
+
+func TestCheckMeasurer(t *testing.T) {
+	if err := CheckMeasurer(measures.Sum[int]{}, smallInt); err != nil {
+		t.Fatal(err)
+	}
+	if err := CheckMeasurer(measures.Count[int]{}, smallInt); err != nil {
+		t.Fatal(err)
+	}
+	var c *Counterexample[int, int]
+	err := CheckMeasurer(weighted{}, smallInt)
+	if !errors.As(err, &c) {
+		t.Fatalf("expected a counterexample but got %v", err)
+	} else if !errors.Is(err, ft.ErrBadMeasurer) {
+		t.Fatalf("expected a bad measurer error but got %v", err)
+	} else if c.Law != "associativity" {
+		t.Fatalf("expected associativity to fail but got %s", c.Law)
+	}
+	err = CheckMeasurer(joins{}, smallInt)
+	if !errors.As(err, &c) || c.Law != "left identity" {
+		t.Fatalf("expected left identity to fail but got %v", err)
+	}
+	var cm *Counterexample[int, map[int]bool]
+	err = CheckMeasurer(mutating{}, smallInt)
+	if !errors.As(err, &cm) || cm.Law != "unchanged arguments to Sum" {
+		t.Fatalf("expected changed arguments to fail but got %v", err)
+	}
+}
+
+func TestRun(t *testing.T) {
+	r := rand.New(rand.NewPCG(3, 4))
+	for range 200 {
+		data := make([]byte, r.IntN(200))
+		for i := range data {
+			data[i] = byte(r.IntN(256))
+		}
+		if err := Run(measures.Sum[int]{}, smallInt, data); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestRunBadMeasurer(t *testing.T) {
+	data := make([]byte, 300)
+	r := rand.New(rand.NewPCG(5, 6))
+	for i := range data {
+		data[i] = byte(r.IntN(256))
+	}
+	if err := Run(weighted{}, smallInt, data); !errors.Is(err, ErrMismatch) {
+		t.Fatalf("expected a mismatch but got %v", err)
+	}
+}
+
+func FuzzSum(f *testing.F) {
+	Fuzz(f, measures.Sum[int]{}, smallInt)
+}
+
+func TestRunFuncNaN(t *testing.T) {
+	gen := func(r *rand.Rand) float64 {
+		if r.IntN(4) == 0 {
+			return math.NaN()
+		}
+		return float64(r.IntN(100))
+	}
+	eq := func(a, b float64) bool {
+		return a == b || math.IsNaN(a) && math.IsNaN(b)
+	}
+	data := []byte{1, 1, 1, 1, 0, 0, 1, 4, 3, 5, 9, 1, 1, 2, 3}
+	if err := RunFunc(measures.Sum[float64]{}, gen, data, eq); err != nil {
+		t.Fatal(err)
+	}
+}
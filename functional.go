@@ -0,0 +1,99 @@
+package lazyfingertree
+
+// Return a tree with f applied to each value, measured with measurer. The
+// result has the same shape as t so nodes are re-measured bottom-up instead
+// of being rebuilt. F is called on the values in order.
+func Map[MS2 Measurer[V2, M2], MS Measurer[V, M], V, M, V2, M2 any](t FingerTree[MS, V, M], f func(V) V2, measurer MS2) FingerTree[MS2, V2, M2] {
+	if t.f == nil {
+		return FingerTree[MS2, V2, M2]{f: newEmptyTree[V2, M2](measurer)}
+	}
+	return FingerTree[MS2, V2, M2]{f: mapTree[V, M, V2, M2](t.forward(), f, measurer)}
+}
+
+func mapTree[V, M, V2, M2 any](t fingerTree[V, M], f func(V) V2, measurer Measurer[V2, M2]) fingerTree[V2, M2] {
+	switch t := force(t).(type) {
+	case *singleTree[V, M]:
+		return newSingleTree(measurer, mapElem(t.value, f, measurer))
+	case *deepTree[V, M]:
+		left := mapDigit(t.left, f, measurer)
+		mid := mapTree(t.mid, f, measurer)
+		return newDeepTree(measurer, left, mid, mapDigit(t.right, f, measurer))
+	}
+	return newEmptyTree(measurer)
+}
+
+func mapDigit[V, M, V2, M2 any](d *digit[V, M], f func(V) V2, measurer Measurer[V2, M2]) *digit[V2, M2] {
+	var items [4]elem[V2, M2]
+	for i, e := range d.elems() {
+		items[i] = mapElem(e, f, measurer)
+	}
+	return newDigit(measurer, items[:d.len()]...)
+}
+
+func mapElem[V, M, V2, M2 any](e elem[V, M], f func(V) V2, measurer Measurer[V2, M2]) elem[V2, M2] {
+	if e.node == nil {
+		return leaf[V2, M2](f(e.value))
+	}
+	var items [3]elem[V2, M2]
+	children := e.node.elems()
+	for i, child := range children {
+		items[i] = mapElem(child, f, measurer)
+	}
+	return newNode(measurer, items[:len(children)]...).asElem()
+}
+
+// Return a tree of the values where keep returns true, built in linear time
+func Filter[MS Measurer[V, M], V, M any](t FingerTree[MS, V, M], keep func(V) bool) FingerTree[MS, V, M] {
+	meas := t.measurerOrZero()
+	b := newTreeBuilder(meas)
+	if t.f != nil {
+		t.each(func(v V) bool {
+			if keep(v) {
+				b.add(leaf[V, M](v))
+			}
+			return true
+		})
+	}
+	return FingerTree[MS, V, M]{f: b.build()}
+}
+
+// Return a tree of the values where pred returns true and a tree of the rest,
+// built in linear time
+func Partition[MS Measurer[V, M], V, M any](t FingerTree[MS, V, M], pred func(V) bool) (FingerTree[MS, V, M], FingerTree[MS, V, M]) {
+	meas := t.measurerOrZero()
+	yes := newTreeBuilder(meas)
+	no := newTreeBuilder(meas)
+	if t.f != nil {
+		t.each(func(v V) bool {
+			if pred(v) {
+				yes.add(leaf[V, M](v))
+			} else {
+				no.add(leaf[V, M](v))
+			}
+			return true
+		})
+	}
+	return FingerTree[MS, V, M]{f: yes.build()}, FingerTree[MS, V, M]{f: no.build()}
+}
+
+// Combine the values from first to last, starting with acc
+func FoldLeft[MS Measurer[V, M], V, M, A any](t FingerTree[MS, V, M], acc A, f func(A, V) A) A {
+	if t.f != nil {
+		t.each(func(v V) bool {
+			acc = f(acc, v)
+			return true
+		})
+	}
+	return acc
+}
+
+// Combine the values from last to first, starting with acc
+func FoldRight[MS Measurer[V, M], V, M, A any](t FingerTree[MS, V, M], acc A, f func(V, A) A) A {
+	if t.f != nil {
+		t.eachReverse(func(v V) bool {
+			acc = f(v, acc)
+			return true
+		})
+	}
+	return acc
+}
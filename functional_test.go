@@ -0,0 +1,40 @@
+package lazyfingertree
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+type byteLen struct{}
+
+func (byteLen) Identity() int        { return 0 }
+func (byteLen) Measure(s string) int { return len(s) }
+func (byteLen) Sum(a int, b int) int { return a + b }
+
+func TestMap(t *testing.T) {
+	tree := lazyTree(1000)
+	strs := Map(tree, func(v int) string { return fmt.Sprint(v) }, byteLen{})
+	expected := 0
+	for _, v := range tree.ToSlice() {
+		expected += len(fmt.Sprint(v))
+	}
+	failIfNot(t, strs.Measure() == expected)
+	_, v, ok := strs.Lookup(func(m int) bool { return m > 10 })
+	failIfNot(t, ok && v == "10")
+	failIfNot(t, Map(newTree[int](), func(v int) string { return fmt.Sprint(v) }, byteLen{}).IsEmpty())
+}
+
+func TestFilterAndFold(t *testing.T) {
+	tree := lazyTree(1000)
+	evens := Filter(tree, func(v int) bool { return v%2 == 0 })
+	yes, no := Partition(tree, func(v int) bool { return v%2 == 0 })
+	failIfNot(t, evens.Measure() == 500 && same(evens.ToSlice(), yes.ToSlice()))
+	failIfNot(t, no.Measure() == 500 && no.PeekFirst() == 1)
+	failIfNot(t, FoldLeft(tree, 0, func(acc, v int) int { return acc + v }) == 999*1000/2)
+	digits := func(tree FingerTree[width[int, int], int, int]) string {
+		return FoldRight(tree, "", func(v int, acc string) string { return fmt.Sprint(v) + acc })
+	}
+	failIfNot(t, digits(newTree(1, 2, 3)) == "123")
+	failIfNot(t, strings.HasPrefix(digits(tree), "0123456789"))
+}
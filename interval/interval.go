@@ -0,0 +1,177 @@
+// Package interval implements persistent interval trees on finger trees, as in
+// Hinze and Paterson's paper. Intervals are closed and ordered by their low
+// endpoints. The measure of a subtree is its largest low endpoint together
+// with its largest high endpoint, so overlap queries can skip every subtree
+// that ends before the query starts. Finding one overlap takes O(log n) time
+// and finding all k overlapping intervals takes O(log n + k log(n/k)) time,
+// not O(log n + k), because intervals that overlap a range need not be next
+// to each other.
+package interval
+
+import (
+	"cmp"
+	"iter"
+
+	ft "github.com/leisure-tools/lazyfingertree"
+)
+
+// An Interval is a closed range from Lo to Hi with an associated value
+type Interval[T, V any] struct {
+	Lo    T
+	Hi    T
+	Value V
+}
+
+type measure[T any] struct {
+	maxLo T
+	maxHi T
+	ok    bool // false for the empty measure
+	size  int
+}
+
+type measurer[T, V any] struct {
+	compare func(a, b T) int
+}
+
+func (m measurer[T, V]) Identity() measure[T] {
+	return measure[T]{}
+}
+
+func (m measurer[T, V]) Measure(iv Interval[T, V]) measure[T] {
+	return measure[T]{iv.Lo, iv.Hi, true, 1}
+}
+
+func (m measurer[T, V]) Sum(a measure[T], b measure[T]) measure[T] {
+	if !a.ok {
+		return b
+	} else if !b.ok {
+		return a
+	}
+	// intervals are ordered by Lo so b's is the largest
+	a.maxLo = b.maxLo
+	if m.compare(b.maxHi, a.maxHi) > 0 {
+		a.maxHi = b.maxHi
+	}
+	a.size += b.size
+	return a
+}
+
+type tree[T, V any] = ft.FingerTree[measurer[T, V], Interval[T, V], measure[T]]
+
+// A Tree is a persistent collection of intervals.
+// The zero value is not usable, create trees with [New] or [NewFunc].
+type Tree[T, V any] struct {
+	tree    tree[T, V]
+	compare func(a, b T) int
+}
+
+// Create an empty tree for ordered endpoints
+func New[T cmp.Ordered, V any]() Tree[T, V] {
+	return NewFunc[T, V](cmp.Compare[T])
+}
+
+// Create an empty tree that orders endpoints with compare, which returns a
+// negative number, zero, or a positive number like [cmp.Compare]
+func NewFunc[T, V any](compare func(a, b T) int) Tree[T, V] {
+	return Tree[T, V]{ft.FromArray(measurer[T, V]{compare}, []Interval[T, V](nil)), compare}
+}
+
+func (t Tree[T, V]) with(tr tree[T, V]) Tree[T, V] {
+	return Tree[T, V]{tr, t.compare}
+}
+
+// The first interval with a low endpoint greater than lo
+func (t Tree[T, V]) loGreater(lo T) ft.Predicate[measure[T]] {
+	return func(m measure[T]) bool {
+		return m.ok && t.compare(m.maxLo, lo) > 0
+	}
+}
+
+// The first interval with a low endpoint of at least lo
+func (t Tree[T, V]) loAtLeast(lo T) ft.Predicate[measure[T]] {
+	return func(m measure[T]) bool {
+		return m.ok && t.compare(m.maxLo, lo) >= 0
+	}
+}
+
+// The first interval with a high endpoint of at least hi
+func (t Tree[T, V]) hiAtLeast(hi T) ft.Predicate[measure[T]] {
+	return func(m measure[T]) bool {
+		return m.ok && t.compare(m.maxHi, hi) >= 0
+	}
+}
+
+// Return the number of intervals
+func (t Tree[T, V]) Len() int {
+	return t.tree.Measure().size
+}
+
+// Return whether the tree is empty
+func (t Tree[T, V]) IsEmpty() bool {
+	return t.tree.IsEmpty()
+}
+
+// Return a tree with the interval added after any others with the same low endpoint
+func (t Tree[T, V]) Insert(lo, hi T, value V) Tree[T, V] {
+	left, right := t.tree.Split(t.loGreater(lo))
+	return t.with(left.AddLast(Interval[T, V]{lo, hi, value}).Concat(right))
+}
+
+// Return a tree without the first interval from lo to hi whose value satisfies
+// match, or the first one from lo to hi if match is nil
+func (t Tree[T, V]) Delete(lo, hi T, match func(V) bool) Tree[T, V] {
+	left, right := t.tree.Split(t.loAtLeast(lo))
+	candidates := right.TakeUntil(t.loGreater(lo))
+	index := -1
+	i := 0
+	candidates.Each(func(iv Interval[T, V]) bool {
+		if t.compare(iv.Hi, hi) == 0 && (match == nil || match(iv.Value)) {
+			index = i
+			return false
+		}
+		i++
+		return true
+	})
+	if index == -1 {
+		return t
+	}
+	before, after := right.Split(func(m measure[T]) bool { return m.size > index })
+	return t.with(left.Concat(before).Concat(after.RemoveFirst()))
+}
+
+// Return an interval that overlaps lo to hi, if there is one
+func (t Tree[T, V]) AnyOverlap(lo, hi T) (Interval[T, V], bool) {
+	// the first interval that ends at or after lo overlaps if it starts by hi
+	_, iv, ok := t.tree.Lookup(t.hiAtLeast(lo))
+	if !ok || t.compare(iv.Lo, hi) > 0 {
+		return Interval[T, V]{}, false
+	}
+	return iv, true
+}
+
+// Iterate over the intervals that overlap lo to hi, in order of their low
+// endpoints. Each one is found with a search that skips the intervals between
+// it and the one before that end before lo, so finding k overlaps takes
+// O(log n + k log(n/k)) time.
+func (t Tree[T, V]) AllOverlaps(lo, hi T) iter.Seq[Interval[T, V]] {
+	return func(yield func(Interval[T, V]) bool) {
+		candidates := t.tree.TakeUntil(t.loGreater(hi))
+		for {
+			candidates = candidates.DropUntil(t.hiAtLeast(lo))
+			if candidates.IsEmpty() || !yield(candidates.PeekFirst()) {
+				return
+			}
+			candidates = candidates.RemoveFirst()
+		}
+	}
+}
+
+// Iterate over the intervals that contain the point
+func (t Tree[T, V]) Stabbing(point T) iter.Seq[Interval[T, V]] {
+	return t.AllOverlaps(point, point)
+}
+
+// Iterate over all of the intervals in order of their low endpoints
+func (t Tree[T, V]) All() iter.Seq[Interval[T, V]] {
+	return t.tree.Seq()
+}
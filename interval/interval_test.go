@@ -0,0 +1,64 @@
+package interval
+
+import (
+	"math/rand"
+	"slices"
+	"testing"
+)
+
+func overlaps(iv Interval[int, int], lo, hi int) bool {
+	return iv.Lo <= hi && lo <= iv.Hi
+}
+
+func TestQueries(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	tree := New[int, int]()
+	var model []Interval[int, int]
+	for i := range 500 {
+		lo := rng.Intn(1000)
+		iv := Interval[int, int]{lo, lo + rng.Intn(50), i}
+		tree = tree.Insert(iv.Lo, iv.Hi, iv.Value)
+		model = append(model, iv)
+	}
+	slices.SortStableFunc(model, func(a, b Interval[int, int]) int { return a.Lo - b.Lo })
+	if !slices.Equal(slices.Collect(tree.All()), model) {
+		t.Fatal("intervals are not ordered by low endpoint")
+	}
+	for range 200 {
+		lo := rng.Intn(1100) - 50
+		hi := lo + rng.Intn(30)
+		var expected []Interval[int, int]
+		for _, iv := range model {
+			if overlaps(iv, lo, hi) {
+				expected = append(expected, iv)
+			}
+		}
+		if found := slices.Collect(tree.AllOverlaps(lo, hi)); !slices.Equal(found, expected) {
+			t.Fatalf("expected %v but got %v", expected, found)
+		}
+		iv, ok := tree.AnyOverlap(lo, hi)
+		if ok != (len(expected) > 0) || ok && !overlaps(iv, lo, hi) {
+			t.Fatalf("bad AnyOverlap for %d-%d", lo, hi)
+		}
+	}
+	for _, iv := range slices.Collect(tree.Stabbing(500)) {
+		if iv.Lo > 500 || iv.Hi < 500 {
+			t.Fatalf("%v does not contain 500", iv)
+		}
+	}
+}
+
+func TestDelete(t *testing.T) {
+	tree := New[int, string]().Insert(1, 5, "a").Insert(1, 5, "b").Insert(1, 3, "c").Insert(0, 9, "d")
+	tree = tree.Delete(1, 5, func(v string) bool { return v == "b" })
+	var values []string
+	for iv := range tree.All() {
+		values = append(values, iv.Value)
+	}
+	if !slices.Equal(values, []string{"d", "a", "c"}) {
+		t.Fatalf("unexpected intervals after delete: %v", values)
+	}
+	if tree.Delete(2, 5, nil).Len() != 3 || tree.Delete(1, 3, nil).Len() != 2 {
+		t.Fatal("bad delete")
+	}
+}
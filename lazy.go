@@ -0,0 +1,59 @@
+package lazyfingertree
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// A lazy is a value that is computed at most once and then published to all
+// goroutines, like a sync.Once that carries its result. Once the value is
+// computed, reading it only costs an atomic load.
+type lazy[T any] struct {
+	done  atomic.Bool
+	mutex sync.Mutex
+	value T
+}
+
+// Return the value, calling compute to produce it if this is the first request.
+// Compute must not (directly or indirectly) ask this lazy for its own value.
+func (l *lazy[T]) get(compute func() T) T {
+	if l.done.Load() {
+		return l.value
+	}
+	return l.getSlow(compute)
+}
+
+func (l *lazy[T]) getSlow(compute func() T) T {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	if !l.done.Load() {
+		l.value = compute()
+		l.done.Store(true)
+	}
+	return l.value
+}
+
+// Set the value directly. This is only safe before the lazy is shared.
+func (l *lazy[T]) set(value T) {
+	l.value = value
+	l.done.Store(true)
+}
+
+// Return the value if it has been computed, without computing it
+func (l *lazy[T]) peek() (T, bool) {
+	if l.done.Load() {
+		return l.value, true
+	}
+	return null[T](), false
+}
+
+// A lazyMeasure is the measure of a digit or node whose items are not all
+// measured yet. It sums them the first time it is needed.
+type lazyMeasure[M any] struct {
+	sum   func() M
+	value lazy[M]
+}
+
+func (l *lazyMeasure[M]) get() M {
+	return l.value.get(l.sum)
+}
@@ -1,7 +1,13 @@
 package lazyfingertree
 
 import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"runtime/debug"
+	"sync"
 	"testing"
 )
 
@@ -139,3 +145,257 @@ func TestSimple(t *testing.T) {
 		testTree(t, i)
 	}
 }
+
+// Build a tree whose spine is full of unforced delayed mids and cached measures
+// that have not been computed yet.
+func lazyTree(size int) FingerTree[width[int, int], int, int] {
+	nums := make([]int, size)
+	for i := range nums {
+		nums[i] = i
+	}
+	tree := newTree(nums...)
+	for i := 1; i < size; i += max(1, size/7) {
+		left, right := tree.Split(func(w int) bool { return w > i })
+		tree = left.Concat(right)
+	}
+	return tree.RemoveFirst().AddFirst(0).RemoveLast().AddLast(size - 1)
+}
+
+func TestConcurrentReads(t *testing.T) {
+	const size = 500
+	const workers = 8
+	for round := 0; round < 10; round++ {
+		tree := lazyTree(size)
+		var wg sync.WaitGroup
+		errs := make(chan string, workers*4)
+		for w := 0; w < workers; w++ {
+			wg.Add(1)
+			go func(w int) {
+				defer wg.Done()
+				if tree.Measure() != size {
+					errs <- fmt.Sprintf("bad measure: %d", tree.Measure())
+				}
+				i := (w*37 + round) % size
+				left, right := tree.Split(func(m int) bool { return m > i })
+				if left.Measure() != i || right.PeekFirst() != i {
+					errs <- fmt.Sprintf("bad split at %d", i)
+				}
+				count := 0
+				tree.Each(func(v int) bool {
+					if v != count {
+						errs <- fmt.Sprintf("bad value %d at %d", v, count)
+						return false
+					}
+					count++
+					return true
+				})
+				if count != size {
+					errs <- fmt.Sprintf("bad count: %d", count)
+				}
+				if !same(tree.RemoveLast().ToSlice(), tree.TakeUntil(func(m int) bool { return m >= size }).ToSlice()) {
+					errs <- "RemoveLast and TakeUntil disagree"
+				}
+			}(w)
+		}
+		wg.Wait()
+		close(errs)
+		for err := range errs {
+			t.Error(err)
+		}
+	}
+}
+
+func TestBulkConstruction(t *testing.T) {
+	for size := 0; size <= 200; size++ {
+		nums := make([]int, size)
+		for i := range nums {
+			nums[i] = i
+		}
+		tree := newTree(nums...)
+		failIfNot(t, tree.Measure() == size)
+		failIfNot(t, same(tree.ToSlice(), nums))
+		seq := FromSeq(newWidth[int](), tree.Seq())
+		failIfNot(t, same(seq.ToSlice(), nums))
+		verifyTree(t, seq, 0, size)
+		half := size / 2
+		appended := newTree(nums[:half]...).AppendSlice(nums[half:])
+		failIfNot(t, same(appended.ToSlice(), nums))
+		verifyTree(t, appended, 0, size)
+		prepended := newTree(nums[half:]...).PrependSlice(nums[:half])
+		failIfNot(t, same(prepended.ToSlice(), nums))
+		verifyTree(t, prepended, 0, size)
+	}
+}
+
+func TestLargeConstruction(t *testing.T) {
+	const size = 1_000_000
+	nums := make([]int, size)
+	for i := range nums {
+		nums[i] = i
+	}
+	tree := newTree(nums...)
+	failIfNot(t, tree.Measure() == size)
+	for _, i := range []int{0, 1, 2, 3, 1000, size / 2, size - 4, size - 1} {
+		_, right := tree.Split(func(w int) bool { return w > i })
+		failIfNot(t, right.PeekFirst() == i)
+	}
+	failIfNot(t, tree.PeekLast() == size-1)
+}
+
+func TestLookup(t *testing.T) {
+	for _, size := range []int{0, 1, 2, 5, 9, 40, 333} {
+		tree := newTree[int]()
+		if size > 0 {
+			tree = lazyTree(size)
+		}
+		for i := 0; i <= size; i++ {
+			prefix, value, ok := tree.Lookup(func(w int) bool { return w > i })
+			if i == size {
+				failIfNot(t, !ok)
+				continue
+			}
+			left, right := tree.Split(func(w int) bool { return w > i })
+			failIfNot(t, ok && prefix == left.Measure() && value == right.PeekFirst() && value == i)
+		}
+	}
+}
+
+type offsetWidth struct {
+	offset int
+}
+
+func (w offsetWidth) Identity() int {
+	return 0
+}
+
+func (w offsetWidth) Measure(v int) int {
+	return 1 + w.offset
+}
+
+func (w offsetWidth) Sum(a int, b int) int {
+	return a + b
+}
+
+func TestTryOperations(t *testing.T) {
+	empty := newTree[int]()
+	_, err := empty.TryPeekFirst()
+	failIfNot(t, errors.Is(err, ErrEmptyTree))
+	_, err = empty.TryPeekLast()
+	failIfNot(t, errors.Is(err, ErrEmptyTree))
+	_, err = empty.TryRemoveFirst()
+	failIfNot(t, errors.Is(err, ErrEmptyTree))
+	_, err = empty.TryRemoveLast()
+	failIfNot(t, errors.Is(err, ErrEmptyTree))
+	_, err = TryConcat[width[int, int], int, int]()
+	failIfNot(t, errors.Is(err, ErrEmptyTree))
+	var zero FingerTree[width[int, int], int, int]
+	_, err = zero.TryMeasure()
+	failIfNot(t, errors.Is(err, ErrBadValue))
+	tree := newTree(1, 2, 3)
+	first, err := tree.TryPeekFirst()
+	failIfErrNow(t, err)
+	failIfNot(t, first == 1)
+	last, err := tree.TryPeekLast()
+	failIfErrNow(t, err)
+	failIfNot(t, last == 3)
+	rest, err := tree.TryRemoveFirst()
+	failIfErrNow(t, err)
+	failIfNot(t, same(rest.ToSlice(), []int{2, 3}))
+	all, err := TryConcat(tree, rest, empty)
+	failIfErrNow(t, err)
+	failIfNot(t, same(all.ToSlice(), []int{1, 2, 3, 2, 3}))
+	a := FromArray[Measurer[int, int]](offsetWidth{0}, []int{1, 2})
+	b := FromArray[Measurer[int, int]](newWidth[int](), []int{3, 4})
+	_, err = a.TryConcat(b)
+	failIfNot(t, errors.Is(err, ErrBadMeasurer))
+	_, err = a.TryConcat(FromArray[Measurer[int, int]](offsetWidth{0}, []int{3, 4}))
+	failIfErrNow(t, err)
+	// measurers of the same type are compatible even if their fields differ
+	var calls1, calls2 int
+	c := FromArray(countingWidth{&calls1}, []int{1, 2})
+	all2, err := c.TryConcat(FromArray(countingWidth{&calls2}, []int{3, 4}))
+	failIfErrNow(t, err)
+	failIfNot(t, all2.Measure() == 4)
+}
+
+func TestLookupAllocs(t *testing.T) {
+	tree := lazyTree(10_000)
+	pos := 5000
+	pred := func(w int) bool { return w > pos }
+	tree.Lookup(pred)
+	if allocs := testing.AllocsPerRun(100, func() { tree.Lookup(pred) }); allocs != 0 {
+		t.Errorf("Lookup allocated %v times", allocs)
+	}
+}
+
+type document struct {
+	Name  string
+	Lines FingerTree[width[string, int], string, int]
+}
+
+func TestCodecs(t *testing.T) {
+	lines := []string{"one", "two", "three", "four", "five"}
+	doc := document{"doc", FromArray(newWidth[string](), lines)}
+	data, err := json.Marshal(doc)
+	failIfErrNow(t, err)
+	failIfNot(t, string(data) == `{"Name":"doc","Lines":["one","two","three","four","five"]}`)
+	var jdoc document
+	failIfErrNow(t, json.Unmarshal(data, &jdoc))
+	failIfNot(t, same(jdoc.Lines.ToSlice(), lines) && jdoc.Lines.Measure() == len(lines))
+	direct, err := doc.Lines.MarshalJSON()
+	failIfErrNow(t, err)
+	failIfNot(t, string(direct) == `["one","two","three","four","five"]`)
+	var ndoc document
+	failIfErrNow(t, json.Unmarshal([]byte(`{"Name":"empty","Lines":null}`), &ndoc))
+	failIfNot(t, ndoc.Name == "empty" && ndoc.Lines.IsZero())
+	// zero trees and empty trees both round trip
+	data, err = json.Marshal(ndoc)
+	failIfErrNow(t, err)
+	failIfNot(t, string(data) == `{"Name":"empty","Lines":null}`)
+	ndoc.Lines = FromArray(newWidth[string](), []string{})
+	data, err = json.Marshal(ndoc)
+	failIfErrNow(t, err)
+	failIfNot(t, string(data) == `{"Name":"empty","Lines":[]}`)
+	ndoc = document{}
+	failIfErrNow(t, json.Unmarshal(data, &ndoc))
+	failIfNot(t, !ndoc.Lines.IsZero() && ndoc.Lines.IsEmpty())
+	// a zero tree with an interface measurer type has no measurer to decode with
+	var itree FingerTree[Measurer[int, int], int, int]
+	failIfNot(t, errors.Is(itree.GobDecode(nil), ErrBadValue))
+	failIfNot(t, errors.Is(json.Unmarshal([]byte(`[1]`), &itree), ErrBadValue))
+	var buf bytes.Buffer
+	failIfErrNow(t, gob.NewEncoder(&buf).Encode(doc))
+	var gdoc document
+	failIfErrNow(t, gob.NewDecoder(&buf).Decode(&gdoc))
+	failIfNot(t, same(gdoc.Lines.ToSlice(), lines) && gdoc.Lines.Measure() == len(lines))
+	buf.Reset()
+	failIfErrNow(t, lazyTree(1000).Encode(&buf))
+	data = buf.Bytes()
+	tree, err := Decode(bytes.NewReader(data), newWidth[int]())
+	failIfErrNow(t, err)
+	verifyTree(t, tree, 0, 1000)
+	tree, err = DecodeN(bytes.NewReader(data), newWidth[int](), 10)
+	failIfErrNow(t, err)
+	verifyTree(t, tree, 0, 10)
+	_, err = Decode(bytes.NewReader(data[:len(data)/2]), newWidth[int]())
+	failIfNot(t, err != nil)
+}
+
+func TestSplitAround(t *testing.T) {
+	tree := lazyTree(1000)
+	values := tree.ToSlice()
+	for _, tr := range []FingerTree[width[int, int], int, int]{tree, tree.Reverse()} {
+		if tr.IsReversed() {
+			values = reversed(values)
+		}
+		for _, i := range []int{0, 1, 10, 500, 998, 999} {
+			left, v, right, prefix, ok := tr.SplitAround(func(m int) bool { return m > i })
+			failIfNot(t, ok && prefix == i && v == values[i])
+			failIfNot(t, same(left.ToSlice(), values[:i]) && same(right.ToSlice(), values[i+1:]))
+		}
+		left, _, right, prefix, ok := tr.SplitAround(func(m int) bool { return m > 1000 })
+		failIfNot(t, !ok && prefix == 1000 && left.Measure() == 1000 && right.IsEmpty())
+	}
+	_, _, _, _, ok := newTree[int]().SplitAround(func(m int) bool { return true })
+	failIfNot(t, !ok)
+}
@@ -0,0 +1,222 @@
+// Package measures provides common measurers, combinators that put several
+// measures in one tree, and predicates to split trees on them.
+//
+// Combinators need their type parameters spelled out because Go can't infer
+// them from measurer arguments. For example, a tree of prices that can be
+// split by index or by running total:
+//
+//	ms := measures.Pair[float64, int, float64](measures.Count[float64]{}, measures.Sum[float64]{})
+//	t := lazyfingertree.FromArray(ms, prices)
+//	left, right := t.Split(measures.OnB[int](measures.Exceeds(100.0)))
+package measures
+
+import (
+	"cmp"
+
+	ft "github.com/leisure-tools/lazyfingertree"
+)
+
+// Number is the set of types that Sum can add
+type Number interface {
+	~int | ~int8 | ~int16 | ~int32 | ~int64 |
+		~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64 | ~uintptr |
+		~float32 | ~float64
+}
+
+// An Option is a value that might be missing. It's used for measures that
+// have no natural identity, like the minimum of an empty tree.
+type Option[T any] struct {
+	Value T
+	Valid bool
+}
+
+// Return a present Option
+func Some[T any](value T) Option[T] {
+	return Option[T]{value, true}
+}
+
+// Count measures the number of values in a tree
+type Count[V any] struct{}
+
+func (c Count[V]) Identity() int {
+	return 0
+}
+
+func (c Count[V]) Measure(v V) int {
+	return 1
+}
+
+func (c Count[V]) Sum(a int, b int) int {
+	return a + b
+}
+
+// Sum measures the total of the values in a tree
+type Sum[N Number] struct{}
+
+func (s Sum[N]) Identity() N {
+	return 0
+}
+
+func (s Sum[N]) Measure(v N) N {
+	return v
+}
+
+func (s Sum[N]) Sum(a N, b N) N {
+	return a + b
+}
+
+// Min measures the smallest value in a tree
+type Min[N cmp.Ordered] struct{}
+
+func (m Min[N]) Identity() Option[N] {
+	return Option[N]{}
+}
+
+func (m Min[N]) Measure(v N) Option[N] {
+	return Some(v)
+}
+
+func (m Min[N]) Sum(a Option[N], b Option[N]) Option[N] {
+	if !a.Valid || b.Valid && b.Value < a.Value {
+		return b
+	}
+	return a
+}
+
+// Max measures the largest value in a tree
+type Max[N cmp.Ordered] struct{}
+
+func (m Max[N]) Identity() Option[N] {
+	return Option[N]{}
+}
+
+func (m Max[N]) Measure(v N) Option[N] {
+	return Some(v)
+}
+
+func (m Max[N]) Sum(a Option[N], b Option[N]) Option[N] {
+	if !a.Valid || b.Valid && b.Value > a.Value {
+		return b
+	}
+	return a
+}
+
+// First measures the first value in a tree
+type First[V any] struct{}
+
+func (f First[V]) Identity() Option[V] {
+	return Option[V]{}
+}
+
+func (f First[V]) Measure(v V) Option[V] {
+	return Some(v)
+}
+
+func (f First[V]) Sum(a Option[V], b Option[V]) Option[V] {
+	if a.Valid {
+		return a
+	}
+	return b
+}
+
+// Last measures the last value in a tree
+type Last[V any] struct{}
+
+func (l Last[V]) Identity() Option[V] {
+	return Option[V]{}
+}
+
+func (l Last[V]) Measure(v V) Option[V] {
+	return Some(v)
+}
+
+func (l Last[V]) Sum(a Option[V], b Option[V]) Option[V] {
+	if b.Valid {
+		return b
+	}
+	return a
+}
+
+// Func is a measurer made from functions, see [MeasurerFunc]
+type Func[V, M any] struct {
+	identity M
+	measure  func(V) M
+	sum      func(a, b M) M
+}
+
+// Make a measurer from an identity and measure and sum functions
+func MeasurerFunc[V, M any](identity M, measure func(V) M, sum func(a, b M) M) Func[V, M] {
+	return Func[V, M]{identity, measure, sum}
+}
+
+func (f Func[V, M]) Identity() M {
+	return f.identity
+}
+
+func (f Func[V, M]) Measure(v V) M {
+	return f.measure(v)
+}
+
+func (f Func[V, M]) Sum(a M, b M) M {
+	return f.sum(a, b)
+}
+
+// PairMeasure holds two measures of the same values
+type PairMeasure[A, B any] struct {
+	A A
+	B B
+}
+
+// PairMeasurer combines two measurers, see [Pair]
+type PairMeasurer[V, A, B any] struct {
+	a ft.Measurer[V, A]
+	b ft.Measurer[V, B]
+}
+
+// Combine two measurers so one tree can be split on either measure
+func Pair[V, A, B any](a ft.Measurer[V, A], b ft.Measurer[V, B]) PairMeasurer[V, A, B] {
+	return PairMeasurer[V, A, B]{a, b}
+}
+
+func (p PairMeasurer[V, A, B]) Identity() PairMeasure[A, B] {
+	return PairMeasure[A, B]{p.a.Identity(), p.b.Identity()}
+}
+
+func (p PairMeasurer[V, A, B]) Measure(v V) PairMeasure[A, B] {
+	return PairMeasure[A, B]{p.a.Measure(v), p.b.Measure(v)}
+}
+
+func (p PairMeasurer[V, A, B]) Sum(x PairMeasure[A, B], y PairMeasure[A, B]) PairMeasure[A, B] {
+	return PairMeasure[A, B]{p.a.Sum(x.A, y.A), p.b.Sum(x.B, y.B)}
+}
+
+// TripleMeasure holds three measures of the same values
+type TripleMeasure[A, B, C any] struct {
+	A A
+	B B
+	C C
+}
+
+// TripleMeasurer combines three measurers, see [Triple]
+type TripleMeasurer[V, A, B, C any] struct {
+	a ft.Measurer[V, A]
+	b ft.Measurer[V, B]
+	c ft.Measurer[V, C]
+}
+
+// Combine three measurers so one tree can be split on any of the measures
+func Triple[V, A, B, C any](a ft.Measurer[V, A], b ft.Measurer[V, B], c ft.Measurer[V, C]) TripleMeasurer[V, A, B, C] {
+	return TripleMeasurer[V, A, B, C]{a, b, c}
+}
+
+func (t TripleMeasurer[V, A, B, C]) Identity() TripleMeasure[A, B, C] {
+	return TripleMeasure[A, B, C]{t.a.Identity(), t.b.Identity(), t.c.Identity()}
+}
+
+func (t TripleMeasurer[V, A, B, C]) Measure(v V) TripleMeasure[A, B, C] {
+	return TripleMeasure[A, B, C]{t.a.Measure(v), t.b.Measure(v), t.c.Measure(v)}
+}
+
+func (t TripleMeasurer[V, A, B, C]) Sum(x TripleMeasure[A, B, C], y TripleMeasure[A, B, C]) TripleMeasure[A, B, C] {
+	return TripleMeasure[A, B, C]{t.a.Sum(x.A, y.A), t.b.Sum(x.B, y.B), t.c.Sum(x.C, y.C)}
+}
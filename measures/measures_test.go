@@ -0,0 +1,63 @@
+package measures
+
+import (
+	"slices"
+	"testing"
+
+	ft "github.com/leisure-tools/lazyfingertree"
+)
+
+func TestPair(t *testing.T) {
+	values := []float64{10, 20, 30, 40, 50}
+	tree := ft.FromArray(Pair[float64, int, float64](Count[float64]{}, Sum[float64]{}), values)
+	if m := tree.Measure(); m.A != 5 || m.B != 150 {
+		t.Fatalf("unexpected measure: %v", m)
+	}
+	left, right := tree.Split(OnA[float64](AtIndex(2)))
+	if !slices.Equal(left.ToSlice(), values[:2]) || !slices.Equal(right.ToSlice(), values[2:]) {
+		t.Fatal("bad split by index")
+	}
+	prefix, v, ok := tree.Lookup(OnB[int](Exceeds(55.0)))
+	if !ok || v != 30 || prefix.A != 2 || prefix.B != 30 {
+		t.Fatalf("bad lookup by sum: %v %v", prefix, v)
+	}
+}
+
+func TestOptionMeasures(t *testing.T) {
+	values := []int{5, 3, 8, 1, 9, 2}
+	if m := ft.FromArray(Min[int]{}, values).Measure(); m != Some(1) {
+		t.Fatalf("bad min: %v", m)
+	}
+	maxTree := ft.FromArray(Max[int]{}, values)
+	if m := maxTree.Measure(); m != Some(9) {
+		t.Fatalf("bad max: %v", m)
+	}
+	if _, v, _ := maxTree.Lookup(MaxAtLeast(8)); v != 8 {
+		t.Fatalf("expected 8 but got %d", v)
+	}
+	if _, v, _ := ft.FromArray(Min[int]{}, values).Lookup(MinAtMost(2)); v != 1 {
+		t.Fatalf("expected 1 but got %d", v)
+	}
+	if m := ft.FromArray(First[int]{}, values).Measure(); m != Some(5) {
+		t.Fatalf("bad first: %v", m)
+	}
+	if m := ft.FromArray(Last[int]{}, values).Measure(); m != Some(2) {
+		t.Fatalf("bad last: %v", m)
+	}
+	if m := ft.FromArray(Max[int]{}, []int(nil)).Measure(); m.Valid {
+		t.Fatal("empty max should not be valid")
+	}
+}
+
+func TestTripleAndFunc(t *testing.T) {
+	lengths := MeasurerFunc(0, func(s string) int { return len(s) }, func(a, b int) int { return a + b })
+	ms := Triple[string, int, int, Option[string]](Count[string]{}, lengths, Last[string]{})
+	tree := ft.FromArray(ms, []string{"a", "bb", "ccc"})
+	if m := tree.Measure(); m.A != 3 || m.B != 6 || m.C != Some("ccc") {
+		t.Fatalf("unexpected measure: %v", m)
+	}
+	chars := Project(func(m TripleMeasure[int, int, Option[string]]) int { return m.B }, Exceeds(2))
+	if _, v, _ := tree.Lookup(chars); v != "bb" {
+		t.Fatalf("expected bb but got %s", v)
+	}
+}
@@ -0,0 +1,66 @@
+package measures
+
+import (
+	"cmp"
+
+	ft "github.com/leisure-tools/lazyfingertree"
+)
+
+// Split a [Count] tree before index i, so the left tree has i values
+func AtIndex(i int) ft.Predicate[int] {
+	return func(n int) bool {
+		return n > i
+	}
+}
+
+// Split before the first value where the running measure exceeds x,
+// for instance a [Sum] tree at a running total
+func Exceeds[N cmp.Ordered](x N) ft.Predicate[N] {
+	return func(n N) bool {
+		return n > x
+	}
+}
+
+// Split before the first value where the running measure reaches x
+func AtLeast[N cmp.Ordered](x N) ft.Predicate[N] {
+	return func(n N) bool {
+		return n >= x
+	}
+}
+
+// Split a [Max] tree before the first value that is at least x
+func MaxAtLeast[N cmp.Ordered](x N) ft.Predicate[Option[N]] {
+	return func(o Option[N]) bool {
+		return o.Valid && o.Value >= x
+	}
+}
+
+// Split a [Min] tree before the first value that is at most x
+func MinAtMost[N cmp.Ordered](x N) ft.Predicate[Option[N]] {
+	return func(o Option[N]) bool {
+		return o.Valid && o.Value <= x
+	}
+}
+
+// Apply a predicate to part of a measure
+func Project[M, P any](part func(M) P, pred ft.Predicate[P]) ft.Predicate[M] {
+	return func(m M) bool {
+		return pred(part(m))
+	}
+}
+
+// Apply a predicate to the first measure of a [Pair].
+// The type of the second measure must be given, as in OnA[float64](AtIndex(3)).
+func OnA[B, A any](pred ft.Predicate[A]) ft.Predicate[PairMeasure[A, B]] {
+	return func(m PairMeasure[A, B]) bool {
+		return pred(m.A)
+	}
+}
+
+// Apply a predicate to the second measure of a [Pair].
+// The type of the first measure must be given, as in OnB[int](Exceeds(2.5)).
+func OnB[A, B any](pred ft.Predicate[B]) ft.Predicate[PairMeasure[A, B]] {
+	return func(m PairMeasure[A, B]) bool {
+		return pred(m.B)
+	}
+}
@@ -0,0 +1,85 @@
+package lazyfingertree
+
+// A mirror builds a tree from another one lazily. Mid trees are built when
+// they are forced, like delayed trees, and nodes are built with their
+// children left unbuilt until something looks inside them. It applies f to
+// each value and, if reverse is set, puts the values in reverse order.
+//
+// Measure converts a measure of the source into a measure of the copy, so
+// copying a node does not need to measure its values. It must agree with
+// measurer like the measure of [MapViewMeasure]. When measure is nil the
+// copied nodes and digits measure their contents when their measures are
+// first needed, and searches look inside them instead, so a search only
+// measures the values before the one it finds.
+type mirror[V, M, V2, M2 any] struct {
+	f        func(V) V2
+	measurer Measurer[V2, M2]
+	measure  func(M) M2
+	reverse  bool
+}
+
+func (m *mirror[V, M, V2, M2]) tree(t fingerTree[V, M]) fingerTree[V2, M2] {
+	if d, ok := t.(*delayed[V, M]); ok {
+		if _, forced := d.delayedTree.peek(); !forced {
+			return newDelayed(func() fingerTree[V2, M2] { return m.tree(d.force()) })
+		}
+	}
+	switch t := force(t).(type) {
+	case *singleTree[V, M]:
+		return newSingleTree(m.measurer, m.elem(t.value))
+	case *deepTree[V, M]:
+		left, right := t.left, t.right
+		if m.reverse {
+			left, right = right, left
+		}
+		mid := t.mid
+		result := newDeepTree(m.measurer, m.digit(left), newDelayed(func() fingerTree[V2, M2] {
+			return m.tree(mid)
+		}), m.digit(right))
+		if m.measure != nil {
+			if known, ok := t._measurement.peek(); ok {
+				result._measurement.set(m.measure(known))
+			}
+		}
+		return result
+	}
+	return newEmptyTree(m.measurer)
+}
+
+// Copy items into dest, in reverse if the mirror reverses
+func (m *mirror[V, M, V2, M2]) elems(dest []elem[V2, M2], items []elem[V, M]) {
+	for i, e := range items {
+		if m.reverse {
+			i = len(items) - 1 - i
+		}
+		dest[i] = m.elem(e)
+	}
+}
+
+func (m *mirror[V, M, V2, M2]) digit(d *digit[V, M]) *digit[V2, M2] {
+	var items [4]elem[V2, M2]
+	m.elems(items[:d.size], d.elems())
+	if m.measure == nil {
+		return newDigit(m.measurer, items[:d.size]...)
+	}
+	return &digit[V2, M2]{_measurement: m.measure(d.getMeasurement()), size: d.size, items: items}
+}
+
+func (m *mirror[V, M, V2, M2]) elem(e elem[V, M]) elem[V2, M2] {
+	if e.node == nil {
+		return leaf[V2, M2](m.f(e.value))
+	}
+	src := e.node
+	n := &node[V2, M2]{size: src.size}
+	n.pending = &pendingNode[V2, M2]{children: &lazyChildren[V2, M2]{build: func() *[3]elem[V2, M2] {
+		var children [3]elem[V2, M2]
+		m.elems(children[:src.size], src.elems())
+		return &children
+	}}}
+	if m.measure == nil {
+		n.pending.measure = n.sumLater(m.measurer)
+	} else {
+		n._measurement = m.measure(src.measurement())
+	}
+	return n.asElem()
+}
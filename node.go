@@ -6,32 +6,64 @@ import (
 )
 
 // A node is a measured container of either 2 or 3 sub-finger-trees.
-type node struct {
-	_measurement measurement
-	children     []any
+type node[V, M any] struct {
+	// nil unless the children or the measure are computed when first used
+	pending      *pendingNode[V, M]
+	_measurement M
+	size         int
+	children     [3]elem[V, M]
 }
 
-func asNode(v any) *node {
-	if n, ok := v.(*node); !ok {
-		panic(ErrExpectedNode)
+// The parts of a node that are computed when first used, each is nil if the
+// node already has it
+type pendingNode[V, M any] struct {
+	children *lazyChildren[V, M]
+	measure  *lazyMeasure[M]
+}
+
+type lazyChildren[V, M any] struct {
+	build func() *[3]elem[V, M]
+	value lazy[*[3]elem[V, M]]
+}
+
+func newNode[V, M any](measurer Measurer[V, M], items ...elem[V, M]) *node[V, M] {
+	n := &node[V, M]{size: len(items)}
+	copy(n.children[:], items)
+	if m, ok := knownSum(measurer, items); ok {
+		n._measurement = m
 	} else {
-		return n
+		n.pending = &pendingNode[V, M]{measure: n.sumLater(measurer)}
 	}
+	return n
 }
 
-func newNode(measurer measurer, items []any) *node {
-	m := measurer.Identity()
-	for _, item := range items {
-		m = measurer.Sum(m, measurer.Measure(item))
+// Return a lazy measure that sums the node's children
+func (n *node[V, M]) sumLater(measurer Measurer[V, M]) *lazyMeasure[M] {
+	return &lazyMeasure[M]{sum: func() M {
+		return sumElems(measurer, measurer.Identity(), n.elems())
+	}}
+}
+
+func (n *node[V, M]) measurement() M {
+	if n.pending == nil || n.pending.measure == nil {
+		return n._measurement
 	}
-	return &node{measurement{measurer, m}, items}
+	return n.pending.measure.get()
 }
 
-func (n *node) String() string {
+// Return the node's measure if it is known without measuring its children
+func (n *node[V, M]) knownMeasure() (M, bool) {
+	if n.pending == nil || n.pending.measure == nil {
+		return n._measurement, true
+	}
+	return n.pending.measure.value.peek()
+}
+
+func (n *node[V, M]) String() string {
 	var b strings.Builder
 	first := true
 	b.WriteString("node{")
-	for _, i := range n.children {
+	for _, i := range n.elems() {
 		if first {
 			first = false
 		} else {
@@ -43,12 +75,55 @@ func (n *node) String() string {
 	return b.String()
 }
 
-func (n *node) toDigit() *digit {
-	return &digit{n._measurement, n.children}
+// The node's children. The result must not be modified.
+func (n *node[V, M]) elems() []elem[V, M] {
+	if n.pending == nil || n.pending.children == nil {
+		return n.children[:n.size]
+	}
+	return n.pending.children.get()[:n.size]
 }
 
-func (n *node) Each(f iterFunc) bool {
-	for _, item := range n.children {
+// Return the node's children if they have been built, without building them
+func (n *node[V, M]) builtElems() ([]elem[V, M], bool) {
+	if n.pending != nil && n.pending.children != nil {
+		children, ok := n.pending.children.value.peek()
+		if !ok {
+			return nil, false
+		}
+		return children[:n.size], true
+	}
+	return n.children[:n.size], true
+}
+
+func (c *lazyChildren[V, M]) get() *[3]elem[V, M] {
+	return c.value.get(c.compute)
+}
+
+// called at most once, under the lazy's lock
+func (c *lazyChildren[V, M]) compute() *[3]elem[V, M] {
+	children := c.build()
+	// release the closure so it doesn't pin the node it copies
+	c.build = nil
+	return children
+}
+
+func (n *node[V, M]) asElem() elem[V, M] {
+	return elem[V, M]{node: n}
+}
+
+func (n *node[V, M]) toDigit() *digit[V, M] {
+	d := &digit[V, M]{size: n.size}
+	copy(d.items[:], n.elems())
+	if m, ok := n.knownMeasure(); ok {
+		d._measurement = m
+	} else {
+		d.measure = n.pending.measure
+	}
+	return d
+}
+
+func (n *node[V, M]) Each(f IterFunc[V]) bool {
+	for _, item := range n.elems() {
 		if !iterateEach(item, f) {
 			return false
 		}
@@ -56,10 +131,10 @@ func (n *node) Each(f iterFunc) bool {
 	return true
 }
 
-func (n *node) EachReverse(f iterFunc) bool {
-	for i := len(n.children); i > 0; {
+func (n *node[V, M]) EachReverse(f IterFunc[V]) bool {
+	for i := n.size; i > 0; {
 		i--
-		if !iterateEachReverse(n.children[i], f) {
+		if !iterateEachReverse(n.elems()[i], f) {
 			return false
 		}
 	}
@@ -75,18 +150,18 @@ func Dup[V any](slice []V) []V {
 // Helper function to group an array of elements into an array of nodes.
 // m: measurer for nodes
 // items: items
-// returns array of nodes
-func nodes(m measurer, items []any) []*node {
-	return nnodes(m, items, []*node{})
-}
-func nnodes(m measurer, items []any, result []*node) []*node {
-	switch len(items) {
-	case 2, 3:
-		return append(result, newNode(m, items))
-	case 4:
-		return append(result, newNode(m, Dup(items[:2])), newNode(m, Dup(items[2:])))
-	default:
-		result = append(result, newNode(m, Dup(items[:3])))
-		return nnodes(m, items[3:], result)
+// returns array of node elems
+func nodes[V, M any](m Measurer[V, M], items []elem[V, M]) []elem[V, M] {
+	result := make([]elem[V, M], 0, (len(items)+2)/3)
+	for {
+		switch len(items) {
+		case 2, 3:
+			return append(result, newNode(m, items...).asElem())
+		case 4:
+			return append(result, newNode(m, items[:2]...).asElem(), newNode(m, items[2:]...).asElem())
+		default:
+			result = append(result, newNode(m, items[:3]...).asElem())
+			items = items[3:]
+		}
 	}
 }
@@ -0,0 +1,252 @@
+// Package ordered implements persistent ordered sets and maps on finger trees.
+// Entries are kept in key order and the measure of a subtree is its largest key,
+// so lookups, insertions, and deletions split the tree in O(log n) time. Union,
+// intersection, and difference use the split and merge algorithm from Hinze and
+// Paterson's paper, which takes O(m log(n/m)) time for sets of sizes m <= n.
+package ordered
+
+import (
+	"cmp"
+	"iter"
+
+	ft "github.com/leisure-tools/lazyfingertree"
+)
+
+type entry[K, V any] struct {
+	key   K
+	value V
+}
+
+// The measure of a subtree is its last key, which is its largest, and its size
+type maxKey[K any] struct {
+	key  K
+	ok   bool // false for the empty measure
+	size int
+}
+
+type measurer[K, V any] struct{}
+
+func (m measurer[K, V]) Identity() maxKey[K] {
+	return maxKey[K]{}
+}
+
+func (m measurer[K, V]) Measure(e entry[K, V]) maxKey[K] {
+	return maxKey[K]{e.key, true, 1}
+}
+
+func (m measurer[K, V]) Sum(a maxKey[K], b maxKey[K]) maxKey[K] {
+	if b.ok {
+		b.size += a.size
+		return b
+	}
+	a.size += b.size
+	return a
+}
+
+type tree[K, V any] = ft.FingerTree[measurer[K, V], entry[K, V], maxKey[K]]
+
+func emptyTree[K, V any]() tree[K, V] {
+	return ft.FromArray(measurer[K, V]{}, []entry[K, V](nil))
+}
+
+// A Map is a persistent map with ordered keys.
+// The zero value is not usable, create maps with [NewMap] or [NewMapFunc].
+type Map[K, V any] struct {
+	tree    tree[K, V]
+	compare func(a, b K) int
+}
+
+// Create an empty map for ordered keys
+func NewMap[K cmp.Ordered, V any]() Map[K, V] {
+	return NewMapFunc[K, V](cmp.Compare[K])
+}
+
+// Create an empty map that orders keys with compare, which returns a negative
+// number, zero, or a positive number like [cmp.Compare]
+func NewMapFunc[K, V any](compare func(a, b K) int) Map[K, V] {
+	return Map[K, V]{emptyTree[K, V](), compare}
+}
+
+func (m Map[K, V]) with(t tree[K, V]) Map[K, V] {
+	return Map[K, V]{t, m.compare}
+}
+
+// The first entry with a key that is at least k
+func (m Map[K, V]) atLeast(k K) ft.Predicate[maxKey[K]] {
+	return func(mk maxKey[K]) bool {
+		return mk.ok && m.compare(mk.key, k) >= 0
+	}
+}
+
+// The first entry with a key that is greater than k
+func (m Map[K, V]) greater(k K) ft.Predicate[maxKey[K]] {
+	return func(mk maxKey[K]) bool {
+		return mk.ok && m.compare(mk.key, k) > 0
+	}
+}
+
+func (m Map[K, V]) startsWith(t tree[K, V], k K) bool {
+	return !t.IsEmpty() && m.compare(t.PeekFirst().key, k) == 0
+}
+
+// Return the number of entries
+func (m Map[K, V]) Len() int {
+	return m.tree.Measure().size
+}
+
+// Return whether the map is empty
+func (m Map[K, V]) IsEmpty() bool {
+	return m.tree.IsEmpty()
+}
+
+// Return the value for a key and whether the key is present
+func (m Map[K, V]) Get(k K) (value V, ok bool) {
+	_, e, ok := m.tree.Lookup(m.atLeast(k))
+	if !ok || m.compare(e.key, k) != 0 {
+		return value, false
+	}
+	return e.value, true
+}
+
+// Return whether the key is present
+func (m Map[K, V]) Contains(k K) bool {
+	_, ok := m.Get(k)
+	return ok
+}
+
+// Return a map with the key set to the value
+func (m Map[K, V]) Insert(k K, v V) Map[K, V] {
+	left, right := m.tree.Split(m.atLeast(k))
+	if m.startsWith(right, k) {
+		right = right.RemoveFirst()
+	}
+	return m.with(left.AddLast(entry[K, V]{k, v}).Concat(right))
+}
+
+// Return a map without the key
+func (m Map[K, V]) Delete(k K) Map[K, V] {
+	left, right := m.tree.Split(m.atLeast(k))
+	if !m.startsWith(right, k) {
+		return m
+	}
+	return m.with(left.Concat(right.RemoveFirst()))
+}
+
+// Return the entry with the largest key that is less than or equal to k
+func (m Map[K, V]) Floor(k K) (key K, value V, ok bool) {
+	left := m.tree.TakeUntil(m.greater(k))
+	if left.IsEmpty() {
+		return key, value, false
+	}
+	e := left.PeekLast()
+	return e.key, e.value, true
+}
+
+// Return the entry with the smallest key that is greater than or equal to k
+func (m Map[K, V]) Ceiling(k K) (key K, value V, ok bool) {
+	_, e, ok := m.tree.Lookup(m.atLeast(k))
+	return e.key, e.value, ok
+}
+
+// Return the entry with the smallest key
+func (m Map[K, V]) Min() (key K, value V, ok bool) {
+	if m.IsEmpty() {
+		return key, value, false
+	}
+	e := m.tree.PeekFirst()
+	return e.key, e.value, true
+}
+
+// Return the entry with the largest key
+func (m Map[K, V]) Max() (key K, value V, ok bool) {
+	if m.IsEmpty() {
+		return key, value, false
+	}
+	e := m.tree.PeekLast()
+	return e.key, e.value, true
+}
+
+func entries[K, V any](t tree[K, V]) iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		t.Each(func(e entry[K, V]) bool {
+			return yield(e.key, e.value)
+		})
+	}
+}
+
+// Iterate over the entries in key order
+func (m Map[K, V]) All() iter.Seq2[K, V] {
+	return entries(m.tree)
+}
+
+// Iterate over the entries with keys from lo up to but not including hi
+func (m Map[K, V]) Range(lo, hi K) iter.Seq2[K, V] {
+	return entries(m.tree.DropUntil(m.atLeast(lo)).TakeUntil(m.atLeast(hi)))
+}
+
+// Return a map with the entries of both maps. When both have a key, the value
+// comes from m. Both maps must use the same ordering.
+func (m Map[K, V]) Union(other Map[K, V]) Map[K, V] {
+	result := emptyTree[K, V]()
+	a, b := m.tree, other.tree
+	aIsM := true
+	for !a.IsEmpty() && !b.IsEmpty() {
+		x := b.PeekFirst()
+		left, right := a.Split(m.atLeast(x.key))
+		result = result.Concat(left)
+		if m.startsWith(right, x.key) {
+			if aIsM {
+				x = right.PeekFirst()
+			}
+			result = result.AddLast(x)
+			right = right.RemoveFirst()
+			b = b.RemoveFirst()
+		}
+		a, b = b, right
+		aIsM = !aIsM
+	}
+	return m.with(result.Concat(a).Concat(b))
+}
+
+// Return a map with the entries whose keys are in both maps, with the values
+// from m. Both maps must use the same ordering.
+func (m Map[K, V]) Intersection(other Map[K, V]) Map[K, V] {
+	result := emptyTree[K, V]()
+	a, b := m.tree, other.tree
+	aIsM := true
+	for !a.IsEmpty() && !b.IsEmpty() {
+		x := b.PeekFirst()
+		right := a.DropUntil(m.atLeast(x.key))
+		if m.startsWith(right, x.key) {
+			if aIsM {
+				x = right.PeekFirst()
+			}
+			result = result.AddLast(x)
+			right = right.RemoveFirst()
+			b = b.RemoveFirst()
+		}
+		a, b = b, right
+		aIsM = !aIsM
+	}
+	return m.with(result)
+}
+
+// Return a map with the entries of m whose keys are not in other.
+// Both maps must use the same ordering.
+func (m Map[K, V]) Difference(other Map[K, V]) Map[K, V] {
+	result := emptyTree[K, V]()
+	a, b := m.tree, other.tree
+	for !a.IsEmpty() && !b.IsEmpty() {
+		x := b.PeekFirst()
+		left, right := a.Split(m.atLeast(x.key))
+		result = result.Concat(left)
+		if m.startsWith(right, x.key) {
+			right = right.RemoveFirst()
+		}
+		a = right
+		if !a.IsEmpty() {
+			b = b.DropUntil(m.atLeast(a.PeekFirst().key))
+		}
+	}
+	return m.with(result.Concat(a))
+}
@@ -0,0 +1,130 @@
+package ordered
+
+import (
+	"maps"
+	"math/rand"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func checkMap(t *testing.T, m Map[int, string], model map[int]string) {
+	t.Helper()
+	if m.Len() != len(model) {
+		t.Fatalf("expected length %d but got %d", len(model), m.Len())
+	}
+	keys := slices.Sorted(maps.Keys(model))
+	i := 0
+	for k, v := range m.All() {
+		if k != keys[i] || v != model[k] {
+			t.Fatalf("expected %d: %s but got %d: %s", keys[i], model[keys[i]], k, v)
+		}
+		i++
+	}
+}
+
+func randomMap(rng *rand.Rand, n, keys int, value string) (Map[int, string], map[int]string) {
+	m := NewMap[int, string]()
+	model := map[int]string{}
+	for range n {
+		k := rng.Intn(keys)
+		m = m.Insert(k, value)
+		model[k] = value
+	}
+	return m, model
+}
+
+func TestMapOperations(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	m := NewMap[int, string]()
+	model := map[int]string{}
+	for i := range 2000 {
+		k := rng.Intn(300)
+		if rng.Intn(3) == 0 {
+			m = m.Delete(k)
+			delete(model, k)
+		} else {
+			m = m.Insert(k, string(rune('a'+i%26)))
+			model[k] = string(rune('a' + i%26))
+		}
+		if v, ok := m.Get(k); ok != (model[k] != "") || v != model[k] {
+			t.Fatalf("bad get for %d", k)
+		}
+	}
+	checkMap(t, m, model)
+	keys := slices.Sorted(maps.Keys(model))
+	for k := -1; k <= 301; k++ {
+		i, found := slices.BinarySearch(keys, k)
+		ceil, _, ok := m.Ceiling(k)
+		if ok != (i < len(keys)) || ok && ceil != keys[i] {
+			t.Fatalf("bad ceiling for %d", k)
+		}
+		if found {
+			i++
+		}
+		floor, _, ok := m.Floor(k)
+		if ok != (i > 0) || ok && floor != keys[i-1] {
+			t.Fatalf("bad floor for %d", k)
+		}
+	}
+	var inRange []int
+	for k := range m.Range(100, 200) {
+		inRange = append(inRange, k)
+	}
+	lo, _ := slices.BinarySearch(keys, 100)
+	hi, _ := slices.BinarySearch(keys, 200)
+	if !slices.Equal(inRange, keys[lo:hi]) {
+		t.Fatal("bad range")
+	}
+	if k, _, _ := m.Min(); k != keys[0] {
+		t.Fatal("bad min")
+	}
+	if k, _, _ := m.Max(); k != keys[len(keys)-1] {
+		t.Fatal("bad max")
+	}
+}
+
+func TestMapAlgebra(t *testing.T) {
+	rng := rand.New(rand.NewSource(2))
+	for _, sizes := range [][2]int{{0, 10}, {10, 0}, {50, 50}, {5, 500}, {500, 5}, {300, 300}} {
+		a, aModel := randomMap(rng, sizes[0], 400, "a")
+		b, bModel := randomMap(rng, sizes[1], 400, "b")
+		union := maps.Clone(bModel)
+		maps.Copy(union, aModel)
+		checkMap(t, a.Union(b), union)
+		intersection := map[int]string{}
+		difference := map[int]string{}
+		for k, v := range aModel {
+			if _, ok := bModel[k]; ok {
+				intersection[k] = v
+			} else {
+				difference[k] = v
+			}
+		}
+		checkMap(t, a.Intersection(b), intersection)
+		checkMap(t, a.Difference(b), difference)
+	}
+}
+
+func TestSet(t *testing.T) {
+	s := NewSet(5, 3, 9, 3, 1)
+	if !slices.Equal(slices.Collect(s.All()), []int{1, 3, 5, 9}) {
+		t.Fatalf("unexpected set: %v", slices.Collect(s.All()))
+	}
+	other := NewSet(3, 4, 5)
+	if !slices.Equal(slices.Collect(s.Union(other).All()), []int{1, 3, 4, 5, 9}) ||
+		!slices.Equal(slices.Collect(s.Intersection(other).All()), []int{3, 5}) ||
+		!slices.Equal(slices.Collect(s.Difference(other).All()), []int{1, 9}) {
+		t.Fatal("bad set algebra")
+	}
+	if k, ok := s.Floor(4); !ok || k != 3 {
+		t.Fatal("bad floor")
+	}
+	if !s.Insert(4).Contains(4) || s.Delete(3).Contains(3) || s.Contains(4) {
+		t.Fatal("bad insert or delete")
+	}
+	reversed := NewSetFunc(func(a, b string) int { return -strings.Compare(a, b) }, "a", "c", "b")
+	if !slices.Equal(slices.Collect(reversed.All()), []string{"c", "b", "a"}) {
+		t.Fatal("custom comparator was not used")
+	}
+}
@@ -0,0 +1,116 @@
+package ordered
+
+import (
+	"cmp"
+	"iter"
+	"slices"
+
+	ft "github.com/leisure-tools/lazyfingertree"
+)
+
+// A Set is a persistent set with ordered members.
+// The zero value is not usable, create sets with [NewSet] or [NewSetFunc].
+type Set[K any] struct {
+	m Map[K, struct{}]
+}
+
+// Create a set of ordered keys
+func NewSet[K cmp.Ordered](keys ...K) Set[K] {
+	return NewSetFunc(cmp.Compare[K], keys...)
+}
+
+// Create a set that orders keys with compare, which returns a negative
+// number, zero, or a positive number like [cmp.Compare]
+func NewSetFunc[K any](compare func(a, b K) int, keys ...K) Set[K] {
+	sorted := slices.SortedStableFunc(slices.Values(keys), compare)
+	sorted = slices.CompactFunc(sorted, func(a, b K) bool { return compare(a, b) == 0 })
+	entries := make([]entry[K, struct{}], len(sorted))
+	for i, k := range sorted {
+		entries[i].key = k
+	}
+	return Set[K]{Map[K, struct{}]{ft.FromArray(measurer[K, struct{}]{}, entries), compare}}
+}
+
+func keys[K any](seq iter.Seq2[K, struct{}]) iter.Seq[K] {
+	return func(yield func(K) bool) {
+		for k := range seq {
+			if !yield(k) {
+				return
+			}
+		}
+	}
+}
+
+// Return the number of keys
+func (s Set[K]) Len() int {
+	return s.m.Len()
+}
+
+// Return whether the set is empty
+func (s Set[K]) IsEmpty() bool {
+	return s.m.IsEmpty()
+}
+
+// Return whether the key is in the set
+func (s Set[K]) Contains(k K) bool {
+	return s.m.Contains(k)
+}
+
+// Return a set with the key added
+func (s Set[K]) Insert(k K) Set[K] {
+	return Set[K]{s.m.Insert(k, struct{}{})}
+}
+
+// Return a set without the key
+func (s Set[K]) Delete(k K) Set[K] {
+	return Set[K]{s.m.Delete(k)}
+}
+
+// Return the largest key that is less than or equal to k
+func (s Set[K]) Floor(k K) (K, bool) {
+	key, _, ok := s.m.Floor(k)
+	return key, ok
+}
+
+// Return the smallest key that is greater than or equal to k
+func (s Set[K]) Ceiling(k K) (K, bool) {
+	key, _, ok := s.m.Ceiling(k)
+	return key, ok
+}
+
+// Return the smallest key
+func (s Set[K]) Min() (K, bool) {
+	key, _, ok := s.m.Min()
+	return key, ok
+}
+
+// Return the largest key
+func (s Set[K]) Max() (K, bool) {
+	key, _, ok := s.m.Max()
+	return key, ok
+}
+
+// Iterate over the keys in order
+func (s Set[K]) All() iter.Seq[K] {
+	return keys(s.m.All())
+}
+
+// Iterate over the keys from lo up to but not including hi
+func (s Set[K]) Range(lo, hi K) iter.Seq[K] {
+	return keys(s.m.Range(lo, hi))
+}
+
+// Return a set with the keys in either set
+func (s Set[K]) Union(other Set[K]) Set[K] {
+	return Set[K]{s.m.Union(other.m)}
+}
+
+// Return a set with the keys in both sets
+func (s Set[K]) Intersection(other Set[K]) Set[K] {
+	return Set[K]{s.m.Intersection(other.m)}
+}
+
+// Return a set with the keys in s that are not in other
+func (s Set[K]) Difference(other Set[K]) Set[K] {
+	return Set[K]{s.m.Difference(other.m)}
+}
@@ -0,0 +1,167 @@
+// Package pqueue implements persistent priority queues on finger trees, using
+// the max-priority measure from Hinze and Paterson's paper. Queues can be kept
+// as snapshots and merged in O(log n) time. Items with equal priorities come
+// out in the order they were pushed.
+package pqueue
+
+import (
+	"cmp"
+
+	ft "github.com/leisure-tools/lazyfingertree"
+)
+
+type item[P, V any] struct {
+	priority P
+	value    V
+}
+
+// The measure of a subtree is its highest priority and its size
+type maxPriority[P any] struct {
+	priority P
+	ok       bool // false for the empty measure
+	size     int
+}
+
+type measurer[P, V any] struct {
+	cmp func(a, b P) int
+}
+
+func (m measurer[P, V]) Identity() maxPriority[P] {
+	return maxPriority[P]{}
+}
+
+func (m measurer[P, V]) Measure(it item[P, V]) maxPriority[P] {
+	return maxPriority[P]{it.priority, true, 1}
+}
+
+func (m measurer[P, V]) Sum(a maxPriority[P], b maxPriority[P]) maxPriority[P] {
+	if !a.ok {
+		return b
+	} else if b.ok && m.cmp(b.priority, a.priority) > 0 {
+		a.priority = b.priority
+	}
+	a.size += b.size
+	return a
+}
+
+type tree[P, V any] = ft.FingerTree[measurer[P, V], item[P, V], maxPriority[P]]
+
+// A Queue is a persistent max-priority queue.
+// The zero value is not usable, create queues with [New] or [NewFunc].
+type Queue[P, V any] struct {
+	tree    tree[P, V]
+	compare func(a, b P) int
+}
+
+// Create an empty max-priority queue for ordered priorities
+func New[P cmp.Ordered, V any]() Queue[P, V] {
+	return NewFunc[P, V](cmp.Compare[P])
+}
+
+// Create an empty max-priority queue that orders priorities with compare,
+// which returns a negative number, zero, or a positive number like [cmp.Compare]
+func NewFunc[P, V any](compare func(a, b P) int) Queue[P, V] {
+	return Queue[P, V]{ft.FromArray(measurer[P, V]{compare}, []item[P, V](nil)), compare}
+}
+
+// Return the number of items in the queue
+func (q Queue[P, V]) Len() int {
+	return q.tree.Measure().size
+}
+
+// Return whether the queue is empty
+func (q Queue[P, V]) IsEmpty() bool {
+	return q.tree.IsEmpty()
+}
+
+// Return a queue with the value added
+func (q Queue[P, V]) Push(priority P, value V) Queue[P, V] {
+	return Queue[P, V]{q.tree.AddLast(item[P, V]{priority, value}), q.compare}
+}
+
+// The first item that reaches the highest priority
+func (q Queue[P, V]) atMax() ft.Predicate[maxPriority[P]] {
+	top := q.tree.Measure().priority
+	return func(m maxPriority[P]) bool {
+		return m.ok && q.compare(m.priority, top) >= 0
+	}
+}
+
+// Return the highest priority and its value without removing it.
+// Ok is false if the queue is empty.
+func (q Queue[P, V]) PeekMax() (priority P, value V, ok bool) {
+	if q.IsEmpty() {
+		return priority, value, false
+	}
+	_, it, _ := q.tree.Lookup(q.atMax())
+	return it.priority, it.value, true
+}
+
+// Remove the item with the highest priority, returning it and the rest of the
+// queue. If several items have that priority, this removes the first one pushed.
+// Ok is false if the queue is empty.
+func (q Queue[P, V]) PopMax() (priority P, value V, rest Queue[P, V], ok bool) {
+	if q.IsEmpty() {
+		return priority, value, q, false
+	}
+	left, right := q.tree.Split(q.atMax())
+	it := right.PeekFirst()
+	return it.priority, it.value, Queue[P, V]{left.Concat(right.RemoveFirst()), q.compare}, true
+}
+
+// Return a queue with the items from both queues. On ties, items from q come
+// out before items from other. Both queues must use the same ordering.
+func (q Queue[P, V]) Merge(other Queue[P, V]) Queue[P, V] {
+	return Queue[P, V]{q.tree.Concat(other.tree), q.compare}
+}
+
+// A MinQueue is a persistent min-priority queue.
+// The zero value is not usable, create queues with [NewMin] or [NewMinFunc].
+type MinQueue[P, V any] struct {
+	q Queue[P, V]
+}
+
+// Create an empty min-priority queue for ordered priorities
+func NewMin[P cmp.Ordered, V any]() MinQueue[P, V] {
+	return NewMinFunc[P, V](cmp.Compare[P])
+}
+
+// Create an empty min-priority queue that orders priorities with compare
+func NewMinFunc[P, V any](compare func(a, b P) int) MinQueue[P, V] {
+	return MinQueue[P, V]{NewFunc[P, V](func(a, b P) int { return compare(b, a) })}
+}
+
+// Return the number of items in the queue
+func (q MinQueue[P, V]) Len() int {
+	return q.q.Len()
+}
+
+// Return whether the queue is empty
+func (q MinQueue[P, V]) IsEmpty() bool {
+	return q.q.IsEmpty()
+}
+
+// Return a queue with the value added
+func (q MinQueue[P, V]) Push(priority P, value V) MinQueue[P, V] {
+	return MinQueue[P, V]{q.q.Push(priority, value)}
+}
+
+// Return the lowest priority and its value without removing it.
+// Ok is false if the queue is empty.
+func (q MinQueue[P, V]) PeekMin() (priority P, value V, ok bool) {
+	return q.q.PeekMax()
+}
+
+// Remove the item with the lowest priority, returning it and the rest of the
+// queue. If several items have that priority, this removes the first one pushed.
+// Ok is false if the queue is empty.
+func (q MinQueue[P, V]) PopMin() (priority P, value V, rest MinQueue[P, V], ok bool) {
+	priority, value, q.q, ok = q.q.PopMax()
+	return priority, value, q, ok
+}
+
+// Return a queue with the items from both queues. On ties, items from q come
+// out before items from other. Both queues must use the same ordering.
+func (q MinQueue[P, V]) Merge(other MinQueue[P, V]) MinQueue[P, V] {
+	return MinQueue[P, V]{q.q.Merge(other.q)}
+}
@@ -0,0 +1,60 @@
+package pqueue
+
+import (
+	"math/rand"
+	"slices"
+	"testing"
+)
+
+type entry struct {
+	priority int
+	order    int
+}
+
+func TestMaxQueue(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	q := New[int, int]()
+	var model []entry
+	for i := range 500 {
+		p := rng.Intn(20)
+		q = q.Push(p, i)
+		model = append(model, entry{p, i})
+	}
+	snapshot := q
+	// stable sort by descending priority gives the expected order
+	slices.SortStableFunc(model, func(a, b entry) int { return b.priority - a.priority })
+	for _, expected := range model {
+		if p, v, ok := q.PeekMax(); !ok || p != expected.priority || v != expected.order {
+			t.Fatalf("expected peek %v but got %d, %d", expected, p, v)
+		}
+		p, v, rest, ok := q.PopMax()
+		if !ok || p != expected.priority || v != expected.order {
+			t.Fatalf("expected %v but got %d, %d", expected, p, v)
+		}
+		q = rest
+	}
+	if _, _, _, ok := q.PopMax(); ok || !q.IsEmpty() {
+		t.Fatal("expected an empty queue")
+	}
+	if snapshot.Len() != 500 {
+		t.Fatal("popping changed the snapshot")
+	}
+}
+
+func TestMinQueueMerge(t *testing.T) {
+	a := NewMin[int, string]().Push(3, "a3").Push(1, "a1").Push(2, "a2")
+	b := NewMin[int, string]().Push(1, "b1").Push(0, "b0")
+	q := a.Merge(b)
+	var result []string
+	for !q.IsEmpty() {
+		_, v, rest, _ := q.PopMin()
+		result = append(result, v)
+		q = rest
+	}
+	if !slices.Equal(result, []string{"b0", "a1", "b1", "a2", "a3"}) {
+		t.Fatalf("unexpected order: %v", result)
+	}
+	if a.Len() != 3 || b.Len() != 2 {
+		t.Fatal("merging changed the inputs")
+	}
+}
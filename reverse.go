@@ -0,0 +1,72 @@
+package lazyfingertree
+
+import (
+	"iter"
+)
+
+// Return the tree with its values in the opposite order in O(1) time. The
+// values are not copied, the result uses the same low-level tree and runs its
+// operations from the other end. This keeps the measures, so the measurer
+// must be commutative, like counts and sizes. Use [ReverseWith] for other
+// measurers.
+//
+// Concatenating trees that go in different directions, and operations that
+// work on the structure of the tree, like Map, Diff, Transient, MapView, and
+// saving to a Store, need the values in order. They use a mirror of the
+// reversed tree that reuses its measures and is only built where it is used,
+// so searching and editing it stay O(log n). Operations that use all of it,
+// like iterating or saving, cost O(n) time and memory to build it.
+func (t FingerTree[MS, V, M]) Reverse() FingerTree[MS, V, M] {
+	return FingerTree[MS, V, M]{t.f, !t.reversed}
+}
+
+// Return whether the tree is a reversed view from [Reverse]
+func (t FingerTree[MS, V, M]) IsReversed() bool {
+	return t.reversed
+}
+
+// Return a new tree with the values in the opposite order, measured with
+// measurer, in O(1) time. This works for measurers that are not commutative.
+// The new tree is only built where it is used. A search has to measure the
+// values before the one it finds with the new measurer, but it does not build
+// or measure anything after it.
+func (t FingerTree[MS, V, M]) ReverseWith(measurer MS) FingerTree[MS, V, M] {
+	if t.f == nil {
+		return wrapTree[MS, V, M](newEmptyTree[V, M](measurer))
+	}
+	m := &mirror[V, M, V, M]{f: identity[V], measurer: measurer, reverse: !t.reversed}
+	return wrapTree[MS, V, M](m.tree(t.f))
+}
+
+// Return a low-level tree that holds t's values when it has the given
+// direction, mirroring t lazily if it has the other direction
+func (t FingerTree[MS, V, M]) direction(reversed bool) fingerTree[V, M] {
+	if t.reversed == reversed {
+		return t.f
+	}
+	m := &mirror[V, M, V, M]{f: identity[V], measurer: measurerFor(t.f), measure: identity[M], reverse: true}
+	return m.tree(t.f)
+}
+
+func identity[T any](value T) T {
+	return value
+}
+
+// Return the low-level tree in order, mirroring it if t is reversed. This is
+// for operations that work directly on the structure of the tree.
+func (t FingerTree[MS, V, M]) forward() fingerTree[V, M] {
+	if t.f == nil {
+		return nil
+	}
+	return t.direction(false)
+}
+
+func backward[V any](values []V) iter.Seq[V] {
+	return func(yield func(V) bool) {
+		for i := len(values) - 1; i >= 0; i-- {
+			if !yield(values[i]) {
+				return
+			}
+		}
+	}
+}
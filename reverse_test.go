@@ -0,0 +1,105 @@
+package lazyfingertree
+
+import (
+	"slices"
+	"testing"
+)
+
+func reversed(values []int) []int {
+	result := slices.Clone(values)
+	slices.Reverse(result)
+	return result
+}
+
+func TestReverse(t *testing.T) {
+	tree := lazyTree(1000)
+	values := tree.ToSlice()
+	rev := tree.Reverse()
+	failIfNot(t, rev.IsReversed() && !rev.Reverse().IsReversed())
+	failIfNot(t, same(rev.ToSlice(), reversed(values)))
+	failIfNot(t, same(slices.Collect(rev.SeqReverse()), values))
+	failIfNot(t, rev.PeekFirst() == values[999] && rev.PeekLast() == values[0])
+	failIfNot(t, rev.Measure() == 1000)
+	left, right := rev.Split(func(m int) bool { return m > 10 })
+	failIfNot(t, same(left.ToSlice(), reversed(values[990:])))
+	failIfNot(t, same(right.ToSlice(), reversed(values[:990])))
+	failIfNot(t, rev.TakeUntil(func(m int) bool { return m > 1000 }).Measure() == 1000)
+	failIfNot(t, rev.DropUntil(func(m int) bool { return m > 1000 }).IsEmpty())
+	prefix, v, ok := rev.Lookup(func(m int) bool { return m > 10 })
+	failIfNot(t, ok && prefix == 10 && v == values[989])
+	c, ok := rev.Cursor().Seek(func(m int) bool { return m > 10 })
+	v, _ = c.Focus()
+	failIfNot(t, ok && c.Prefix() == 10 && v == values[989])
+	// editing from either end
+	edited := rev.AddFirst(-1).AddLast(-2).RemoveFirst().AddFirst(-3).AppendSlice([]int{-4, -5}).PrependSlice([]int{-6, -7})
+	expected := append(append([]int{-6, -7, -3}, reversed(values)...), -2, -4, -5)
+	failIfNot(t, same(edited.ToSlice(), expected))
+	failIfNot(t, same(edited.Reverse().ToSlice(), reversed(expected)))
+	// joining trees in different directions
+	failIfNot(t, same(rev.Concat(newTree(1, 2)).ToSlice(), append(reversed(values), 1, 2)))
+	failIfNot(t, same(newTree(1, 2).Concat(rev).ToSlice(), append([]int{1, 2}, reversed(values)...)))
+	failIfNot(t, same(rev.Concat(newTree(1, 2).Reverse()).ToSlice(), append(reversed(values), 2, 1)))
+	failIfNot(t, same(Map(rev, func(v int) string { return "" }, byteLen{}).Reverse().ToSlice(), make([]string, 1000)))
+	failIfNot(t, same(FoldLeft(rev, []int{}, func(acc []int, v int) []int { return append(acc, v) }), reversed(values)))
+}
+
+type firstValue struct{}
+
+func (firstValue) Identity() []int            { return nil }
+func (firstValue) Measure(v int) []int        { return []int{v} }
+func (firstValue) Sum(a []int, b []int) []int { return append(slices.Clip(a), b...) }
+
+func TestReverseWith(t *testing.T) {
+	tree := FromArray(firstValue{}, []int{1, 2, 3})
+	rev := tree.ReverseWith(firstValue{})
+	failIfNot(t, !rev.IsReversed() && same(rev.Measure(), []int{3, 2, 1}))
+}
+
+func TestReverseWithLazy(t *testing.T) {
+	calls := 0
+	values := ints(100000)
+	tree := FromArray(countingWidth{&calls}, values)
+	calls = 0
+	rev := tree.ReverseWith(countingWidth{&calls})
+	// only the outer digits are built right away
+	failIfNot(t, calls <= 8 && !rev.IsReversed())
+	left, right := rev.Split(after(10))
+	failIfNot(t, same(left.ToSlice(), reversed(values[99990:])) && right.PeekFirst() == 99989)
+	if calls > 200 {
+		t.Fatalf("measured %d values to split near the start", calls)
+	}
+	// the new measurer measures the values before the split point and the
+	// nodes on its path, nothing after it
+	calls = 0
+	left, right = rev.Split(after(50000))
+	failIfNot(t, left.PeekLast() == 50000 && right.PeekFirst() == 49999)
+	if calls > 50200 {
+		t.Fatalf("measured %d values to split in the middle", calls)
+	}
+	failIfNot(t, same(rev.ToSlice(), reversed(values)) && rev.Measure() == len(values))
+	failIfErrNow(t, rev.Validate())
+	failIfNot(t, same(rev.ReverseWith(countingWidth{&calls}).ToSlice(), values))
+}
+
+func TestConcatDirectionsLazy(t *testing.T) {
+	calls := 0
+	values := ints(100000)
+	tree := FromArray(countingWidth{&calls}, values)
+	small := FromArray(countingWidth{&calls}, []int{-1, -2})
+	calls = 0
+	// joining trees in different directions mirrors one lazily with its
+	// measures, so searching in the middle stays O(log n)
+	joined := small.Concat(tree.Reverse())
+	left, right := joined.Split(after(50000))
+	failIfNot(t, left.PeekLast() == 50002 && right.PeekFirst() == 50001)
+	joined = tree.Reverse().Concat(small)
+	left, right = joined.Split(after(50000))
+	failIfNot(t, left.PeekLast() == 50000 && right.PeekFirst() == 49999)
+	// concatenating and splitting measure the values they put in new nodes
+	if calls > 50 {
+		t.Fatalf("measured %d values", calls)
+	}
+	failIfNot(t, joined.Measure() == len(values)+2)
+	failIfNot(t, same(joined.ToSlice(), append(reversed(values), -1, -2)))
+	failIfErrNow(t, small.Concat(tree.Reverse()).Validate())
+}
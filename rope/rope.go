@@ -0,0 +1,335 @@
+// Package rope implements persistent text ropes on finger trees. A rope stores
+// its text in chunks and measures them in bytes, runes, UTF-16 code units, and
+// newlines at once, so it can convert between any of those offsets and
+// (line, column) positions in O(log n) time.
+package rope
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	ft "github.com/leisure-tools/lazyfingertree"
+)
+
+var ErrOutOfRange = fmt.Errorf("%w, offset out of range", ft.ErrFingerTree)
+
+// Chunks are kept between minChunk and maxChunk bytes where possible
+const (
+	maxChunk = 1024
+	minChunk = maxChunk / 4
+)
+
+// A Measure holds the size of some text in several units
+type Measure struct {
+	Bytes int
+	Runes int
+	UTF16 int
+	Lines int // the number of newlines
+}
+
+func (m Measure) add(other Measure) Measure {
+	return Measure{
+		Bytes: m.Bytes + other.Bytes,
+		Runes: m.Runes + other.Runes,
+		UTF16: m.UTF16 + other.UTF16,
+		Lines: m.Lines + other.Lines,
+	}
+}
+
+// Measure a string
+func MeasureString(s string) Measure {
+	m := Measure{Bytes: len(s)}
+	for _, r := range s {
+		m.Runes++
+		m.UTF16 += utf16Len(r)
+		if r == '\n' {
+			m.Lines++
+		}
+	}
+	return m
+}
+
+func utf16Len(r rune) int {
+	if r >= 0x10000 && r <= utf8.MaxRune {
+		return 2
+	}
+	return 1
+}
+
+type measurer struct{}
+
+func (m measurer) Identity() Measure {
+	return Measure{}
+}
+
+func (m measurer) Measure(chunk string) Measure {
+	return MeasureString(chunk)
+}
+
+func (m measurer) Sum(a Measure, b Measure) Measure {
+	return a.add(b)
+}
+
+type tree = ft.FingerTree[measurer, string, Measure]
+
+// A Position is a zero-based line and a zero-based column counted in runes
+type Position struct {
+	Line   int
+	Column int
+}
+
+// A Rope is a persistent piece of text. The zero value is empty.
+type Rope struct {
+	tree tree
+}
+
+// Create a rope containing the text
+func New(text string) Rope {
+	return Rope{ft.FromArray(measurer{}, chunks(text))}
+}
+
+// Split text into chunks of at most maxChunk bytes at rune boundaries,
+// making the chunks about the same size.
+func chunks(text string) []string {
+	if len(text) == 0 {
+		return nil
+	}
+	count := (len(text) + maxChunk - 1) / maxChunk
+	result := make([]string, 0, count)
+	for ; count > 1; count-- {
+		size := (len(text) + count - 1) / count
+		end := size
+		for end > 0 && !utf8.RuneStart(text[end]) {
+			end--
+		}
+		if end == 0 {
+			// not valid UTF-8, so any boundary will do
+			end = size
+		}
+		result = append(result, text[:end])
+		text = text[end:]
+	}
+	return append(result, text)
+}
+
+func (r Rope) getTree() tree {
+	if r.tree.IsZero() {
+		return ft.FromArray(measurer{}, []string(nil))
+	}
+	return r.tree
+}
+
+// Return the sizes of the text
+func (r Rope) Measure() Measure {
+	if r.tree.IsZero() {
+		return Measure{}
+	}
+	return r.tree.Measure()
+}
+
+// Return the length of the text in bytes
+func (r Rope) Len() int {
+	return r.Measure().Bytes
+}
+
+// Return the number of lines, which is one more than the number of newlines
+func (r Rope) LineCount() int {
+	return r.Measure().Lines + 1
+}
+
+// Return the text
+func (r Rope) String() string {
+	var sb strings.Builder
+	sb.Grow(r.Len())
+	r.getTree().Each(func(chunk string) bool {
+		sb.WriteString(chunk)
+		return true
+	})
+	return sb.String()
+}
+
+// Iterate over the chunks of text
+func (r Rope) Chunks(f func(chunk string) bool) {
+	r.getTree().Each(f)
+}
+
+func (r Rope) checkRange(start, end int) {
+	if start < 0 || end < start || end > r.Len() {
+		panic(fmt.Errorf("%w: [%d:%d], length %d", ErrOutOfRange, start, end, r.Len()))
+	}
+}
+
+// Split the tree at a byte offset, splitting the chunk that straddles it
+func (r Rope) splitAt(offset int) (tree, tree) {
+	left, right := r.getTree().Split(func(m Measure) bool { return m.Bytes > offset })
+	if right.IsEmpty() {
+		return left, right
+	}
+	local := offset - left.Measure().Bytes
+	if local == 0 {
+		return left, right
+	}
+	chunk := right.PeekFirst()
+	if !utf8.RuneStart(chunk[local]) {
+		panic(fmt.Errorf("%w: %d is inside a character", ErrOutOfRange, offset))
+	}
+	return left.AddLast(chunk[:local]), right.RemoveFirst().AddFirst(chunk[local:])
+}
+
+// Join two trees with text in between. Neighboring chunks are merged into the
+// text when either is small and then the text is chunked again, so editing
+// doesn't leave a trail of tiny chunks.
+func join(left tree, text string, right tree) Rope {
+	if !left.IsEmpty() && (len(text) < minChunk || len(left.PeekLast()) < minChunk) {
+		text = left.PeekLast() + text
+		left = left.RemoveLast()
+	}
+	if !right.IsEmpty() && (len(text) < minChunk || len(right.PeekFirst()) < minChunk) {
+		text += right.PeekFirst()
+		right = right.RemoveFirst()
+	}
+	return Rope{left.AppendSlice(chunks(text)).Concat(right)}
+}
+
+// Return a rope with text inserted at a byte offset
+func (r Rope) Insert(offset int, text string) Rope {
+	r.checkRange(offset, offset)
+	if len(text) == 0 {
+		return r
+	}
+	left, right := r.splitAt(offset)
+	return join(left, text, right)
+}
+
+// Return a rope without the bytes from start up to end
+func (r Rope) Delete(start, end int) Rope {
+	r.checkRange(start, end)
+	if start == end {
+		return r
+	}
+	left, _ := r.splitAt(start)
+	_, right := r.splitAt(end)
+	return join(left, "", right)
+}
+
+// Return a rope with the bytes from start up to end
+func (r Rope) Slice(start, end int) Rope {
+	r.checkRange(start, end)
+	left, _ := r.splitAt(end)
+	_, right := Rope{left}.splitAt(start)
+	return Rope{right}
+}
+
+// Return the text from start up to end
+func (r Rope) Substring(start, end int) string {
+	return r.Slice(start, end).String()
+}
+
+// Find the chunk where unit(measure) exceeds n, returning the measure before it
+// and the chunk. The chunk is empty if n is at the end of the text.
+func (r Rope) find(n int, unit func(Measure) int) (Measure, string) {
+	if n < 0 || n > unit(r.Measure()) {
+		panic(fmt.Errorf("%w: %d, length %d", ErrOutOfRange, n, unit(r.Measure())))
+	}
+	prefix, chunk, ok := r.getTree().Lookup(func(m Measure) bool { return unit(m) > n })
+	if !ok {
+		return r.Measure(), ""
+	}
+	return prefix, chunk
+}
+
+// Return the measure of the text before a byte offset
+func (r Rope) MeasureBefore(offset int) Measure {
+	prefix, chunk := r.find(offset, func(m Measure) int { return m.Bytes })
+	local := chunk[:offset-prefix.Bytes]
+	if len(local) < len(chunk) && !utf8.RuneStart(chunk[len(local)]) {
+		panic(fmt.Errorf("%w: %d is inside a character", ErrOutOfRange, offset))
+	}
+	return prefix.add(MeasureString(local))
+}
+
+// Return the byte offset of the start of the text where unit(measure) reaches n.
+// Offsets inside a UTF-16 surrogate pair move back to the start of the pair.
+func (r Rope) byteOffset(n int, unit func(Measure) int) int {
+	prefix, chunk := r.find(n, unit)
+	for len(chunk) > 0 {
+		_, size := utf8.DecodeRuneInString(chunk)
+		next := prefix.add(MeasureString(chunk[:size]))
+		if unit(next) > n {
+			break
+		}
+		prefix = next
+		chunk = chunk[size:]
+	}
+	return prefix.Bytes
+}
+
+// Convert a byte offset to a rune offset
+func (r Rope) ByteToRune(offset int) int {
+	return r.MeasureBefore(offset).Runes
+}
+
+// Convert a rune offset to a byte offset
+func (r Rope) RuneToByte(offset int) int {
+	return r.byteOffset(offset, func(m Measure) int { return m.Runes })
+}
+
+// Convert a byte offset to a UTF-16 offset
+func (r Rope) ByteToUTF16(offset int) int {
+	return r.MeasureBefore(offset).UTF16
+}
+
+// Convert a UTF-16 offset to a byte offset
+func (r Rope) UTF16ToByte(offset int) int {
+	return r.byteOffset(offset, func(m Measure) int { return m.UTF16 })
+}
+
+// Return the byte offset of the start of a line
+func (r Rope) LineStart(line int) int {
+	if line < 0 || line >= r.LineCount() {
+		panic(fmt.Errorf("%w: line %d, line count %d", ErrOutOfRange, line, r.LineCount()))
+	} else if line == 0 {
+		return 0
+	}
+	// the line starts after newline number line, which is where the count
+	// of newlines exceeds line - 1
+	prefix, chunk := r.find(line-1, func(m Measure) int { return m.Lines })
+	lines := prefix.Lines
+	for i := 0; i < len(chunk); i++ {
+		if chunk[i] == '\n' {
+			lines++
+			if lines == line {
+				return prefix.Bytes + i + 1
+			}
+		}
+	}
+	return prefix.Bytes + len(chunk)
+}
+
+// Return the byte offset of the end of a line, not including its newline
+func (r Rope) LineEnd(line int) int {
+	if line == r.LineCount()-1 {
+		return r.Len()
+	}
+	return r.LineStart(line+1) - 1
+}
+
+// Convert a byte offset to a position
+func (r Rope) ByteToPosition(offset int) Position {
+	before := r.MeasureBefore(offset)
+	start := r.LineStart(before.Lines)
+	return Position{Line: before.Lines, Column: before.Runes - r.ByteToRune(start)}
+}
+
+// Convert a position to a byte offset. Columns past the end of the line
+// are moved to the end of the line.
+func (r Rope) PositionToByte(pos Position) int {
+	start := r.LineStart(pos.Line)
+	end := r.LineEnd(pos.Line)
+	runes := r.ByteToRune(start) + pos.Column
+	if runes >= r.ByteToRune(end) {
+		return end
+	}
+	return r.RuneToByte(runes)
+}
@@ -0,0 +1,120 @@
+package rope
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+var alphabet = []rune("abc \né世\U0001F600")
+
+func randomText(rng *rand.Rand, n int) string {
+	var sb strings.Builder
+	for range n {
+		sb.WriteRune(alphabet[rng.Intn(len(alphabet))])
+	}
+	return sb.String()
+}
+
+// Pick a random byte offset on a rune boundary
+func randomOffset(rng *rand.Rand, text string) int {
+	offset := rng.Intn(len(text) + 1)
+	for offset < len(text) && !utf8.RuneStart(text[offset]) {
+		offset--
+	}
+	return offset
+}
+
+func checkRope(t *testing.T, r Rope, expected string) {
+	t.Helper()
+	if r.String() != expected {
+		t.Fatalf("expected %q but got %q", expected, r.String())
+	}
+	if r.Measure() != MeasureString(expected) {
+		t.Fatalf("expected measure %v but got %v", MeasureString(expected), r.Measure())
+	}
+}
+
+func TestEdits(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	text := randomText(rng, 3000)
+	r := New(text)
+	checkRope(t, r, text)
+	for i := range 2000 {
+		if rng.Intn(3) > 0 {
+			offset := randomOffset(rng, text)
+			insert := randomText(rng, rng.Intn(5)+1)
+			if i%100 == 0 {
+				insert = randomText(rng, 2000)
+			}
+			r = r.Insert(offset, insert)
+			text = text[:offset] + insert + text[offset:]
+		} else {
+			start := randomOffset(rng, text)
+			end := min(len(text), randomOffset(rng, text[start:])+start)
+			r = r.Delete(start, end)
+			text = text[:start] + text[end:]
+		}
+		if i%50 == 0 {
+			checkRope(t, r, text)
+		}
+	}
+	checkRope(t, r, text)
+	start := randomOffset(rng, text)
+	end := randomOffset(rng, text[start:]) + start
+	if r.Substring(start, end) != text[start:end] {
+		t.Fatal("bad substring")
+	}
+	chunks := 0
+	r.Chunks(func(chunk string) bool {
+		if len(chunk) > maxChunk+4 {
+			t.Fatalf("chunk is too large: %d", len(chunk))
+		}
+		chunks++
+		return true
+	})
+	if chunks > 2*len(text)/minChunk+2 {
+		t.Fatalf("too many chunks: %d for %d bytes", chunks, len(text))
+	}
+}
+
+func TestConversions(t *testing.T) {
+	rng := rand.New(rand.NewSource(2))
+	text := randomText(rng, 5000)
+	r := New(text)
+	if r.LineCount() != strings.Count(text, "\n")+1 {
+		t.Fatal("bad line count")
+	}
+	runes, units, line, column := 0, 0, 0, 0
+	check := func(offset int) {
+		if r.ByteToRune(offset) != runes || r.RuneToByte(runes) != offset {
+			t.Fatalf("bad rune conversion at %d", offset)
+		}
+		if r.ByteToUTF16(offset) != units || r.UTF16ToByte(units) != offset {
+			t.Fatalf("bad UTF-16 conversion at %d", offset)
+		}
+		pos := r.ByteToPosition(offset)
+		if pos != (Position{line, column}) || r.PositionToByte(pos) != offset {
+			t.Fatalf("bad position at %d: %v", offset, pos)
+		}
+	}
+	for offset, c := range text {
+		check(offset)
+		runes++
+		units += len(utf16.Encode([]rune{c}))
+		column++
+		if c == '\n' {
+			line++
+			column = 0
+			if r.LineStart(line) != offset+1 {
+				t.Fatalf("bad start for line %d", line)
+			}
+		}
+	}
+	check(len(text))
+	if r.PositionToByte(Position{0, 1_000_000}) != strings.Index(text, "\n") {
+		t.Fatal("column past the end of a line should move to the end")
+	}
+}
@@ -0,0 +1,163 @@
+// Package seq implements persistent indexed sequences on finger trees, like
+// Haskell's Data.Sequence. Indexing, updates, insertions, deletions, and
+// splits all take O(log n) time and never modify existing sequences.
+package seq
+
+import (
+	"fmt"
+	"iter"
+
+	ft "github.com/leisure-tools/lazyfingertree"
+	"github.com/leisure-tools/lazyfingertree/measures"
+)
+
+var ErrOutOfRange = fmt.Errorf("%w, index out of range", ft.ErrFingerTree)
+
+// Tree is the finger tree type that underlies a Seq
+type Tree[V any] = ft.FingerTree[measures.Count[V], V, int]
+
+// A Seq is a persistent sequence. The zero value is an empty sequence.
+type Seq[V any] struct {
+	tree Tree[V]
+}
+
+// Create a sequence containing the values
+func New[V any](values ...V) Seq[V] {
+	return Seq[V]{ft.FromArray(measures.Count[V]{}, values)}
+}
+
+// Create a sequence from an iterator
+func FromSeq[V any](values iter.Seq[V]) Seq[V] {
+	return Seq[V]{ft.FromSeq(measures.Count[V]{}, values)}
+}
+
+// Return the underlying tree, which is empty for the zero value
+func (s Seq[V]) Tree() Tree[V] {
+	if s.tree.IsZero() {
+		return ft.FromArray(measures.Count[V]{}, []V(nil))
+	}
+	return s.tree
+}
+
+func (s Seq[V]) checkIndex(i, limit int) {
+	if i < 0 || i >= limit {
+		panic(fmt.Errorf("%w: index %d, length %d", ErrOutOfRange, i, s.Len()))
+	}
+}
+
+// Return the number of values in the sequence
+func (s Seq[V]) Len() int {
+	if s.tree.IsZero() {
+		return 0
+	}
+	return s.tree.Measure()
+}
+
+// Return the value at index i. This panics if i is out of range.
+func (s Seq[V]) Get(i int) V {
+	s.checkIndex(i, s.Len())
+	_, v, _ := s.tree.Lookup(measures.AtIndex(i))
+	return v
+}
+
+// Return a sequence with the value at index i replaced by v.
+// This panics if i is out of range.
+func (s Seq[V]) Set(i int, v V) Seq[V] {
+	s.checkIndex(i, s.Len())
+	left, right := s.tree.Split(measures.AtIndex(i))
+	return Seq[V]{left.AddLast(v).Concat(right.RemoveFirst())}
+}
+
+// Return a sequence with v inserted before index i. I may be the length of the
+// sequence, which adds v to the end. This panics if i is out of range.
+func (s Seq[V]) Insert(i int, v V) Seq[V] {
+	s.checkIndex(i, s.Len()+1)
+	left, right := s.Tree().Split(measures.AtIndex(i))
+	return Seq[V]{left.AddLast(v).Concat(right)}
+}
+
+// Return a sequence without the value at index i.
+// This panics if i is out of range.
+func (s Seq[V]) Delete(i int) Seq[V] {
+	s.checkIndex(i, s.Len())
+	left, right := s.tree.Split(measures.AtIndex(i))
+	return Seq[V]{left.Concat(right.RemoveFirst())}
+}
+
+// Return the values from index i up to but not including index j, like s[i:j].
+// This panics if the indexes are out of range.
+func (s Seq[V]) Slice(i, j int) Seq[V] {
+	if i < 0 || j < i || j > s.Len() {
+		panic(fmt.Errorf("%w: slice [%d:%d], length %d", ErrOutOfRange, i, j, s.Len()))
+	}
+	left, _ := s.Tree().Split(measures.AtIndex(j))
+	_, right := left.Split(measures.AtIndex(i))
+	return Seq[V]{right}
+}
+
+// Split the sequence into the first i values and the rest.
+// This panics if i is out of range.
+func (s Seq[V]) SplitAt(i int) (Seq[V], Seq[V]) {
+	s.checkIndex(i, s.Len()+1)
+	left, right := s.Tree().Split(measures.AtIndex(i))
+	return Seq[V]{left}, Seq[V]{right}
+}
+
+// Return a sequence with the values added to the end
+func (s Seq[V]) Append(values ...V) Seq[V] {
+	if len(values) == 1 {
+		return Seq[V]{s.Tree().AddLast(values[0])}
+	}
+	return Seq[V]{s.Tree().AppendSlice(values)}
+}
+
+// Return a sequence with the values added to the start, keeping their order
+func (s Seq[V]) Prepend(values ...V) Seq[V] {
+	if len(values) == 1 {
+		return Seq[V]{s.Tree().AddFirst(values[0])}
+	}
+	return Seq[V]{s.Tree().PrependSlice(values)}
+}
+
+// Return a sequence with the values of other after the values of s
+func (s Seq[V]) Concat(other Seq[V]) Seq[V] {
+	return Seq[V]{s.Tree().Concat(other.Tree())}
+}
+
+// Iterate over the indexes and values from the start
+func (s Seq[V]) All() iter.Seq2[int, V] {
+	return func(yield func(int, V) bool) {
+		i := 0
+		s.Tree().Each(func(v V) bool {
+			if !yield(i, v) {
+				return false
+			}
+			i++
+			return true
+		})
+	}
+}
+
+// Iterate over the indexes and values from the end
+func (s Seq[V]) Backward() iter.Seq2[int, V] {
+	return func(yield func(int, V) bool) {
+		i := s.Len() - 1
+		s.Tree().EachReverse(func(v V) bool {
+			if !yield(i, v) {
+				return false
+			}
+			i--
+			return true
+		})
+	}
+}
+
+// Iterate over the values from the start
+func (s Seq[V]) Values() iter.Seq[V] {
+	return s.Tree().Seq()
+}
+
+// Return a slice of the values
+func (s Seq[V]) ToSlice() []V {
+	return s.Tree().ToSlice()
+}
@@ -0,0 +1,92 @@
+package seq
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+func nums(n int) []int {
+	result := make([]int, n)
+	for i := range result {
+		result[i] = i
+	}
+	return result
+}
+
+func check(t *testing.T, s Seq[int], expected []int) {
+	t.Helper()
+	if s.Len() != len(expected) {
+		t.Fatalf("expected length %d but got %d", len(expected), s.Len())
+	}
+	if !slices.Equal(s.ToSlice(), expected) {
+		t.Fatalf("expected %v but got %v", expected, s.ToSlice())
+	}
+	for i, v := range expected {
+		if s.Get(i) != v {
+			t.Fatalf("expected %d at %d but got %d", v, i, s.Get(i))
+		}
+	}
+}
+
+func TestEdits(t *testing.T) {
+	for size := 0; size < 40; size++ {
+		s := New(nums(size)...)
+		check(t, s, nums(size))
+		for i := 0; i <= size; i++ {
+			expected := slices.Insert(nums(size), i, -1)
+			check(t, s.Insert(i, -1), expected)
+			left, right := s.SplitAt(i)
+			check(t, left, nums(size)[:i])
+			check(t, right, nums(size)[i:])
+			for j := i; j <= size; j++ {
+				check(t, s.Slice(i, j), nums(size)[i:j])
+			}
+			if i == size {
+				continue
+			}
+			expected = nums(size)
+			expected[i] = -1
+			check(t, s.Set(i, -1), expected)
+			check(t, s.Delete(i), slices.Delete(nums(size), i, i+1))
+		}
+		check(t, s, nums(size))
+	}
+}
+
+func TestZeroAndAppend(t *testing.T) {
+	var s Seq[int]
+	check(t, s, nil)
+	s = s.Append(0).Append(1, 2, 3).Prepend(-2, -1)
+	check(t, s, []int{-2, -1, 0, 1, 2, 3})
+	check(t, s.Concat(New(4, 5)), []int{-2, -1, 0, 1, 2, 3, 4, 5})
+	check(t, FromSeq(slices.Values([]int{7, 8})), []int{7, 8})
+}
+
+func TestIterators(t *testing.T) {
+	s := New(nums(25)...)
+	for i, v := range s.All() {
+		if i != v {
+			t.Fatalf("expected %d but got %d", i, v)
+		}
+	}
+	next := 24
+	for i, v := range s.Backward() {
+		if i != next || v != next {
+			t.Fatalf("expected %d but got %d, %d", next, i, v)
+		}
+		next--
+	}
+	if next != -1 {
+		t.Fatal("Backward stopped early")
+	}
+}
+
+func TestOutOfRange(t *testing.T) {
+	defer func() {
+		if err, ok := recover().(error); !ok || !errors.Is(err, ErrOutOfRange) {
+			t.Fatal("expected ErrOutOfRange")
+		}
+	}()
+	New(1, 2, 3).Get(3)
+}
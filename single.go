@@ -6,105 +6,124 @@ import (
 )
 
 // A finger-tree which contains exactly one element.
-type singleTree struct {
-	_measurement measurement
-	value        any
-}
-
-func newSingleTree(measurer measurer, value any) *singleTree {
-	return &singleTree{newMeasurement(measurer, value), value}
+type singleTree[V, M any] struct {
+	measurer     Measurer[V, M]
+	_measurement M
+	value        elem[V, M]
+	// the value is a node that measures itself when first needed and
+	// _measurement is not set
+	lazyValue bool
+}
+
+func newSingleTree[V, M any](measurer Measurer[V, M], value elem[V, M]) *singleTree[V, M] {
+	if !value.measured() {
+		return &singleTree[V, M]{measurer: measurer, value: value, lazyValue: true}
+	}
+	return &singleTree[V, M]{measurer: measurer, _measurement: value.measure(measurer), value: value}
 }
 
-func (s *singleTree) measurement() measurement {
+func (s *singleTree[V, M]) measurement() M {
+	if s.lazyValue {
+		return s.value.node.measurement()
+	}
 	return s._measurement
 }
 
-type nodeMeasurer struct {
-	measurer measurer
-}
-
-func (m nodeMeasurer) Identity() any {
-	return m.measurer.Identity()
-}
-
-func (m nodeMeasurer) Measure(v any) any {
-	return asNode(v)._measurement.value
-}
-
-func (m nodeMeasurer) Sum(a any, b any) any {
-	return m.measurer.Sum(a, b)
+// Return the tree's measure if it is known without measuring its value
+func (s *singleTree[V, M]) knownMeasure() (M, bool) {
+	if s.lazyValue {
+		return s.value.node.knownMeasure()
+	}
+	return s._measurement, true
 }
 
-func makeEmptyMid(m measurer) fingerTree {
-	return newEmptyTree(nodeMeasurer{m})
+func (s *singleTree[V, M]) getMeasurer() Measurer[V, M] {
+	return s.measurer
 }
 
-func (s *singleTree) String() string {
+func (s *singleTree[V, M]) String() string {
 	return fmt.Sprintf("singleTree{%v}", s.value)
 }
 
 // single ignores level
-func (s *singleTree) Dump(w io.Writer, level int) {
-	fmt.Fprintf(w, "%s %s", s._measurement.value, Brief(s.value))
+func (s *singleTree[V, M]) Dump(w io.Writer, level int) {
+	fmt.Fprintf(w, "%v %s", s.measurement(), Brief(s.value.item()))
 }
 
-func (s *singleTree) AddFirst(value any) fingerTree {
-	m := measurerFor(s)
+func (s *singleTree[V, M]) AddFirst(value elem[V, M]) fingerTree[V, M] {
+	m := s.measurer
 	return newDeepTree(m,
-		newDigit(m, []any{value}),
-		makeEmptyMid(m),
-		newDigit(m, []any{s.value}),
+		newDigit(m, value),
+		newEmptyTree(m),
+		newDigit(m, s.value),
 	)
 }
 
-func (s *singleTree) AddLast(value any) fingerTree {
-	m := measurerFor(s)
+func (s *singleTree[V, M]) AddLast(value elem[V, M]) fingerTree[V, M] {
+	m := s.measurer
 	return newDeepTree(m,
-		newDigit(m, []any{s.value}),
-		makeEmptyMid(m),
-		newDigit(m, []any{value}),
+		newDigit(m, s.value),
+		newEmptyTree(m),
+		newDigit(m, value),
 	)
 }
 
-func (s *singleTree) RemoveFirst() fingerTree {
-	return newEmptyTree(measurerFor(s))
+func (s *singleTree[V, M]) RemoveFirst() fingerTree[V, M] {
+	return newEmptyTree(s.measurer)
 }
 
-func (s *singleTree) RemoveLast() fingerTree {
-	return newEmptyTree(measurerFor(s))
+func (s *singleTree[V, M]) RemoveLast() fingerTree[V, M] {
+	return newEmptyTree(s.measurer)
 }
 
-func (s *singleTree) PeekFirst() any {
+func (s *singleTree[V, M]) PeekFirst() elem[V, M] {
 	return s.value
 }
 
-func (s *singleTree) PeekLast() any {
+func (s *singleTree[V, M]) PeekLast() elem[V, M] {
 	return s.value
 }
 
-func (s *singleTree) Concat(other fingerTree) fingerTree {
+func (s *singleTree[V, M]) Concat(other fingerTree[V, M]) fingerTree[V, M] {
 	return other.AddFirst(s.value)
 }
 
-func (s *singleTree) splitTree(predicate predicate, initial any) (fingerTree, any, fingerTree) {
-	return s._measurement.empty(), s.value, s._measurement.empty()
+func (s *singleTree[V, M]) splitTree(predicate Predicate[M], initial M) (fingerTree[V, M], elem[V, M], fingerTree[V, M]) {
+	return newEmptyTree(s.measurer), s.value, newEmptyTree(s.measurer)
+}
+
+func (s *singleTree[V, M]) lookup(predicate Predicate[M], initial M) (M, elem[V, M]) {
+	return initial, s.value
+}
+
+func (s *singleTree[V, M]) splitTreeRight(predicate Predicate[M], initial M) (fingerTree[V, M], elem[V, M], fingerTree[V, M]) {
+	return newEmptyTree(s.measurer), s.value, newEmptyTree(s.measurer)
+}
+
+func (s *singleTree[V, M]) lookupRight(predicate Predicate[M], initial M) (M, elem[V, M]) {
+	return initial, s.value
 }
 
-func (s *singleTree) Split(predicate predicate) (fingerTree, fingerTree) {
-	if predicate(s._measurement.value) {
-		return s._measurement.empty(), s
+func (s *singleTree[V, M]) Split(predicate Predicate[M]) (fingerTree[V, M], fingerTree[V, M]) {
+	if predicate(s.measurement()) {
+		return newEmptyTree(s.measurer), s
 	}
-	return s, s._measurement.empty()
+	return s, newEmptyTree(s.measurer)
 }
 
-func (s *singleTree) ToSlice() []any {
-	return []any{s.value}
+func (s *singleTree[V, M]) ToSlice() []V {
+	result := make([]V, 0, 1)
+	s.Each(func(value V) bool {
+		result = append(result, value)
+		return true
+	})
+	return result
 }
 
-func (s *singleTree) Each(f iterFunc) bool {
+func (s *singleTree[V, M]) Each(f IterFunc[V]) bool {
 	return iterateEach(s.value, f)
 }
 
-func (s *singleTree) EachReverse(f iterFunc) bool {
+func (s *singleTree[V, M]) EachReverse(f IterFunc[V]) bool {
 	return iterateEachReverse(s.value, f)
 }
@@ -0,0 +1,501 @@
+package lazyfingertree
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"weak"
+)
+
+// A Store saves trees as content-addressed blobs so versions that share
+// structure share blobs. Each node and each deep tree is a blob holding its
+// cached measure and the hashes of its children, so saving a new version only
+// writes the nodes that changed. Blobs have a fixed format, so a tree hashes
+// the same in every process. Values and measures are encoded as JSON, which
+// writes map keys in order, so they must round trip through encoding/json.
+//
+// A Store remembers the hashes of nodes it has saved or loaded for as long as
+// the nodes are alive, so saving an edited version of a tree does not rehash
+// the parts it shares with earlier versions. A Store is not safe for
+// concurrent use.
+type Store[MS Measurer[V, M], V, M any] struct {
+	blobs    BlobStore
+	measurer MS
+	// hashes of live nodes and deep trees that are known to be in blobs
+	nodeHashes map[weak.Pointer[node[V, M]]]Hash
+	treeHashes map[weak.Pointer[deepTree[V, M]]]Hash
+	// live nodes that were loaded, so loads share structure
+	loaded  map[Hash]weak.Pointer[node[V, M]]
+	pruneAt int
+}
+
+const (
+	blobEmpty byte = iota
+	blobSingle
+	blobDeep
+	blobNode
+)
+
+// The stored form of a node or tree. Items are either Values, at the leaves,
+// or the hashes of Nodes. A deep tree keeps its left digit in those fields and
+// its right digit in the Right fields.
+type blob[V, M any] struct {
+	Kind         byte
+	Measure      M
+	Values       []V
+	Nodes        []Hash
+	ItemsMeasure M
+	Mid          Hash
+	RightValues  []V
+	RightNodes   []Hash
+	RightMeasure M
+}
+
+func NewStore[MS Measurer[V, M], V, M any](blobs BlobStore, measurer MS) *Store[MS, V, M] {
+	return &Store[MS, V, M]{
+		blobs:      blobs,
+		measurer:   measurer,
+		nodeHashes: map[weak.Pointer[node[V, M]]]Hash{},
+		treeHashes: map[weak.Pointer[deepTree[V, M]]]Hash{},
+		loaded:     map[Hash]weak.Pointer[node[V, M]]{},
+		pruneAt:    1024,
+	}
+}
+
+// Save the tree and return its root hash. Only blobs that are not already in
+// the store are written.
+func (s *Store[MS, V, M]) Save(t FingerTree[MS, V, M]) (Hash, error) {
+	if t.f == nil {
+		return Hash{}, fmt.Errorf("%w: cannot save an uninitialized tree", ErrBadValue)
+	}
+	h, err := s.saveTree(t.forward())
+	s.prune()
+	return h, err
+}
+
+// Load the tree with the given root hash. Measures are restored from the
+// blobs rather than recomputed.
+func (s *Store[MS, V, M]) Load(h Hash) (FingerTree[MS, V, M], error) {
+	tree, err := s.loadTree(h)
+	s.prune()
+	if err != nil {
+		return FingerTree[MS, V, M]{}, err
+	}
+	return FingerTree[MS, V, M]{f: tree}, nil
+}
+
+// Remove every blob that is not reachable from the given roots and return the
+// number of blobs removed.
+func (s *Store[MS, V, M]) Sweep(roots ...Hash) (int, error) {
+	marked := map[Hash]bool{}
+	for _, root := range roots {
+		if err := s.mark(root, marked); err != nil {
+			return 0, err
+		}
+	}
+	hashes, err := s.blobs.Hashes()
+	if err != nil {
+		return 0, err
+	}
+	// swept blobs may belong to live trees that were never saved under a root
+	clear(s.nodeHashes)
+	clear(s.treeHashes)
+	clear(s.loaded)
+	count := 0
+	for _, h := range hashes {
+		if !marked[h] {
+			if err := s.blobs.Delete(h); err != nil {
+				return count, err
+			}
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (s *Store[MS, V, M]) mark(h Hash, marked map[Hash]bool) error {
+	if marked[h] {
+		return nil
+	}
+	marked[h] = true
+	b, err := s.get(h)
+	if err != nil {
+		return err
+	}
+	for _, children := range [][]Hash{b.Nodes, b.RightNodes} {
+		for _, child := range children {
+			if err := s.mark(child, marked); err != nil {
+				return err
+			}
+		}
+	}
+	if b.Kind == blobDeep {
+		return s.mark(b.Mid, marked)
+	}
+	return nil
+}
+
+// drop hashes for collected nodes, amortized over saves and loads
+func (s *Store[MS, V, M]) prune() {
+	if len(s.nodeHashes)+len(s.treeHashes)+len(s.loaded) < s.pruneAt {
+		return
+	}
+	for p := range s.nodeHashes {
+		if p.Value() == nil {
+			delete(s.nodeHashes, p)
+		}
+	}
+	for p := range s.treeHashes {
+		if p.Value() == nil {
+			delete(s.treeHashes, p)
+		}
+	}
+	for h, p := range s.loaded {
+		if p.Value() == nil {
+			delete(s.loaded, h)
+		}
+	}
+	s.pruneAt = max(1024, 2*(len(s.nodeHashes)+len(s.treeHashes)+len(s.loaded)))
+}
+
+// Encode the blob as its kind followed by the fields that kind uses, in
+// order. Counts and lengths are uvarints, hashes are raw bytes, and measures
+// and values are JSON prefixed by their length.
+func (b *blob[V, M]) encode() ([]byte, error) {
+	w := &blobWriter{buf: []byte{b.Kind}}
+	switch b.Kind {
+	case blobSingle, blobNode:
+		w.json(b.Measure)
+		writeItems(w, b.Values, b.Nodes)
+	case blobDeep:
+		w.json(b.Measure)
+		writeItems(w, b.Values, b.Nodes)
+		w.json(b.ItemsMeasure)
+		w.buf = append(w.buf, b.Mid[:]...)
+		writeItems(w, b.RightValues, b.RightNodes)
+		w.json(b.RightMeasure)
+	}
+	return w.buf, w.err
+}
+
+func decodeBlob[V, M any](data []byte) (*blob[V, M], error) {
+	b := &blob[V, M]{}
+	r := &blobReader{data: data}
+	b.Kind = r.kind()
+	switch b.Kind {
+	case blobEmpty:
+	case blobSingle, blobNode:
+		r.json(&b.Measure)
+		b.Values, b.Nodes = readItems[V](r)
+	case blobDeep:
+		r.json(&b.Measure)
+		b.Values, b.Nodes = readItems[V](r)
+		r.json(&b.ItemsMeasure)
+		b.Mid = r.hash()
+		b.RightValues, b.RightNodes = readItems[V](r)
+		r.json(&b.RightMeasure)
+	default:
+		r.fail("unknown kind %d", b.Kind)
+	}
+	if r.err == nil && len(r.data) > 0 {
+		r.fail("%d extra bytes", len(r.data))
+	}
+	return b, r.err
+}
+
+type blobWriter struct {
+	buf []byte
+	err error
+}
+
+func (w *blobWriter) count(n int) {
+	w.buf = binary.AppendUvarint(w.buf, uint64(n))
+}
+
+func (w *blobWriter) json(v any) {
+	if w.err != nil {
+		return
+	}
+	var data []byte
+	data, w.err = json.Marshal(v)
+	w.count(len(data))
+	w.buf = append(w.buf, data...)
+}
+
+// Items are either values or node hashes, a blob holds one or the other
+func writeItems[V any](w *blobWriter, values []V, nodes []Hash) {
+	w.count(len(values))
+	for i := range values {
+		w.json(&values[i])
+	}
+	w.count(len(nodes))
+	for _, h := range nodes {
+		w.buf = append(w.buf, h[:]...)
+	}
+}
+
+type blobReader struct {
+	data []byte
+	err  error
+}
+
+func (r *blobReader) fail(format string, args ...any) {
+	if r.err == nil {
+		r.err = fmt.Errorf(format, args...)
+	}
+	r.data = nil
+}
+
+func (r *blobReader) kind() byte {
+	if len(r.data) == 0 {
+		r.fail("truncated")
+		return 0
+	}
+	b := r.data[0]
+	r.data = r.data[1:]
+	return b
+}
+
+// Read a count of things that take at least size bytes each
+func (r *blobReader) count(size int) int {
+	n, l := binary.Uvarint(r.data)
+	if l <= 0 || n > uint64(len(r.data)-l)/uint64(size) {
+		r.fail("bad count")
+		return 0
+	}
+	r.data = r.data[l:]
+	return int(n)
+}
+
+func (r *blobReader) json(v any) {
+	n := r.count(1)
+	if r.err != nil {
+		return
+	} else if err := json.Unmarshal(r.data[:n], v); err != nil {
+		r.fail("%w", err)
+		return
+	}
+	r.data = r.data[n:]
+}
+
+func (r *blobReader) hash() (h Hash) {
+	if len(r.data) < len(h) {
+		r.fail("truncated")
+		return h
+	}
+	r.data = r.data[copy(h[:], r.data):]
+	return h
+}
+
+func readItems[V any](r *blobReader) ([]V, []Hash) {
+	var values []V
+	if n := r.count(1); n > 0 {
+		values = make([]V, n)
+		for i := range values {
+			r.json(&values[i])
+		}
+	}
+	var nodes []Hash
+	if n := r.count(len(Hash{})); n > 0 {
+		nodes = make([]Hash, n)
+		for i := range nodes {
+			nodes[i] = r.hash()
+		}
+	}
+	return values, nodes
+}
+
+func (s *Store[MS, V, M]) put(b *blob[V, M]) (Hash, error) {
+	data, err := b.encode()
+	if err != nil {
+		return Hash{}, err
+	}
+	h := hashBlob(data)
+	if has, err := s.blobs.Has(h); err != nil {
+		return h, err
+	} else if !has {
+		return h, s.blobs.Put(h, data)
+	}
+	return h, nil
+}
+
+func (s *Store[MS, V, M]) get(h Hash) (*blob[V, M], error) {
+	data, err := s.blobs.Get(h)
+	if err != nil {
+		return nil, err
+	}
+	b, err := decodeBlob[V, M](data)
+	if err != nil {
+		return nil, fmt.Errorf("%w: blob %s: %w", ErrBadEncoding, h, err)
+	}
+	return b, nil
+}
+
+func (s *Store[MS, V, M]) saveTree(t fingerTree[V, M]) (Hash, error) {
+	b := &blob[V, M]{}
+	var err error
+	switch t := force(t).(type) {
+	case *emptyTree[V, M]:
+		b.Kind = blobEmpty
+	case *singleTree[V, M]:
+		b.Kind = blobSingle
+		b.Measure = t.measurement()
+		b.Values, b.Nodes, err = s.saveItems([]elem[V, M]{t.value})
+	case *deepTree[V, M]:
+		ptr := weak.Make(t)
+		if h, ok := s.treeHashes[ptr]; ok {
+			return h, nil
+		}
+		b.Kind = blobDeep
+		b.Measure = t.measurement()
+		b.ItemsMeasure = t.left.getMeasurement()
+		b.RightMeasure = t.right.getMeasurement()
+		if b.Values, b.Nodes, err = s.saveItems(t.left.elems()); err != nil {
+			return Hash{}, err
+		} else if b.Mid, err = s.saveTree(t.mid); err != nil {
+			return Hash{}, err
+		} else if b.RightValues, b.RightNodes, err = s.saveItems(t.right.elems()); err != nil {
+			return Hash{}, err
+		}
+		h, err := s.put(b)
+		if err == nil {
+			s.treeHashes[ptr] = h
+		}
+		return h, err
+	}
+	if err != nil {
+		return Hash{}, err
+	}
+	return s.put(b)
+}
+
+// Items are either all values or all nodes
+func (s *Store[MS, V, M]) saveItems(items []elem[V, M]) ([]V, []Hash, error) {
+	if len(items) == 0 || items[0].node == nil {
+		values := make([]V, len(items))
+		for i, item := range items {
+			values[i] = item.value
+		}
+		return values, nil, nil
+	}
+	hashes := make([]Hash, len(items))
+	for i, item := range items {
+		h, err := s.saveNode(item.node)
+		if err != nil {
+			return nil, nil, err
+		}
+		hashes[i] = h
+	}
+	return nil, hashes, nil
+}
+
+func (s *Store[MS, V, M]) saveNode(n *node[V, M]) (Hash, error) {
+	ptr := weak.Make(n)
+	if h, ok := s.nodeHashes[ptr]; ok {
+		return h, nil
+	}
+	values, nodes, err := s.saveItems(n.elems())
+	if err != nil {
+		return Hash{}, err
+	}
+	h, err := s.put(&blob[V, M]{Kind: blobNode, Measure: n.measurement(), Values: values, Nodes: nodes})
+	if err == nil {
+		s.nodeHashes[ptr] = h
+	}
+	return h, err
+}
+
+func (s *Store[MS, V, M]) loadTree(h Hash) (fingerTree[V, M], error) {
+	b, err := s.get(h)
+	if err != nil {
+		return nil, err
+	}
+	meas := Measurer[V, M](s.measurer)
+	switch b.Kind {
+	case blobEmpty:
+		return newEmptyTree(meas), nil
+	case blobSingle:
+		items, err := s.loadItems(b.Values, b.Nodes)
+		if err != nil {
+			return nil, err
+		} else if len(items) != 1 {
+			return nil, fmt.Errorf("%w: blob %s: single tree with %d items", ErrBadEncoding, h, len(items))
+		}
+		return &singleTree[V, M]{measurer: meas, _measurement: b.Measure, value: items[0]}, nil
+	case blobDeep:
+		left, err := s.loadDigit(h, b.Values, b.Nodes, b.ItemsMeasure)
+		if err != nil {
+			return nil, err
+		}
+		mid, err := s.loadTree(b.Mid)
+		if err != nil {
+			return nil, err
+		}
+		right, err := s.loadDigit(h, b.RightValues, b.RightNodes, b.RightMeasure)
+		if err != nil {
+			return nil, err
+		}
+		tree := newDeepTree(meas, left, mid, right)
+		tree._measurement.set(b.Measure)
+		s.treeHashes[weak.Make(tree)] = h
+		return tree, nil
+	}
+	return nil, fmt.Errorf("%w: blob %s is not a tree", ErrBadEncoding, h)
+}
+
+func (s *Store[MS, V, M]) loadDigit(h Hash, values []V, nodes []Hash, measure M) (*digit[V, M], error) {
+	items, err := s.loadItems(values, nodes)
+	if err != nil {
+		return nil, err
+	} else if len(items) < 1 || len(items) > 4 {
+		return nil, fmt.Errorf("%w: blob %s: digit with %d items", ErrBadEncoding, h, len(items))
+	}
+	d := &digit[V, M]{_measurement: measure, size: len(items)}
+	copy(d.items[:], items)
+	return d, nil
+}
+
+func (s *Store[MS, V, M]) loadItems(values []V, nodes []Hash) ([]elem[V, M], error) {
+	if len(nodes) == 0 {
+		items := make([]elem[V, M], len(values))
+		for i, v := range values {
+			items[i] = leaf[V, M](v)
+		}
+		return items, nil
+	}
+	items := make([]elem[V, M], len(nodes))
+	for i, h := range nodes {
+		n, err := s.loadNode(h)
+		if err != nil {
+			return nil, err
+		}
+		items[i] = n.asElem()
+	}
+	return items, nil
+}
+
+func (s *Store[MS, V, M]) loadNode(h Hash) (*node[V, M], error) {
+	if p, ok := s.loaded[h]; ok {
+		if n := p.Value(); n != nil {
+			return n, nil
+		}
+	}
+	b, err := s.get(h)
+	if err != nil {
+		return nil, err
+	} else if b.Kind != blobNode {
+		return nil, fmt.Errorf("%w: blob %s is not a node", ErrBadEncoding, h)
+	}
+	items, err := s.loadItems(b.Values, b.Nodes)
+	if err != nil {
+		return nil, err
+	} else if len(items) < 2 || len(items) > 3 {
+		return nil, fmt.Errorf("%w: blob %s: node with %d items", ErrBadEncoding, h, len(items))
+	}
+	n := &node[V, M]{_measurement: b.Measure, size: len(items)}
+	copy(n.children[:], items)
+	ptr := weak.Make(n)
+	s.loaded[h] = ptr
+	s.nodeHashes[ptr] = h
+	return n, nil
+}
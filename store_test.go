@@ -0,0 +1,102 @@
+package lazyfingertree
+
+import (
+	"bytes"
+	"encoding/gob"
+	"errors"
+	"io"
+	"testing"
+)
+
+// counts the blobs written through it
+type countingStore struct {
+	BlobStore
+	puts int
+}
+
+func (s *countingStore) Put(h Hash, data []byte) error {
+	s.puts++
+	return s.BlobStore.Put(h, data)
+}
+
+func TestStoreSharesNodes(t *testing.T) {
+	dir, err := NewDirStore(t.TempDir())
+	failIfErrNow(t, err)
+	blobs := &countingStore{BlobStore: dir}
+	store := NewStore(blobs, newWidth[int]())
+	v1 := lazyTree(10000)
+	h1, err := store.Save(v1)
+	failIfErrNow(t, err)
+	initial := blobs.puts
+	left, right := v1.Split(func(m int) bool { return m > 5000 })
+	v2 := left.AddLast(-1).Concat(right.RemoveFirst())
+	h2, err := store.Save(v2)
+	failIfErrNow(t, err)
+	if written := blobs.puts - initial; written == 0 || written > 100 {
+		t.Fatalf("saving an edit wrote %d blobs, the first version wrote %d", written, initial)
+	}
+	// a fresh store with no memory of the trees must find the same blobs
+	fresh := NewStore(blobs, newWidth[int]())
+	before := blobs.puts
+	h, err := fresh.Save(v2)
+	failIfErrNow(t, err)
+	failIfNot(t, h == h2 && blobs.puts == before)
+	loaded, err := fresh.Load(h2)
+	failIfErrNow(t, err)
+	failIfNot(t, loaded.Measure() == 10000)
+	failIfNot(t, same(loaded.ToSlice(), v2.ToSlice()))
+	removed, err := fresh.Sweep(h2)
+	failIfErrNow(t, err)
+	failIfNot(t, removed > 0)
+	_, err = fresh.Load(h1)
+	failIfNot(t, errors.Is(err, ErrNotFound))
+	loaded, err = fresh.Load(h2)
+	failIfErrNow(t, err)
+	failIfNot(t, same(loaded.ToSlice(), v2.ToSlice()))
+}
+
+func TestPackStore(t *testing.T) {
+	var pack bytes.Buffer
+	store := NewStore(NewPackStore(&pack), newWidth[int]())
+	trees := []FingerTree[width[int, int], int, int]{newTree[int](), newTree(1), lazyTree(1000)}
+	hashes := make([]Hash, len(trees))
+	for i, tree := range trees {
+		h, err := store.Save(tree)
+		failIfErrNow(t, err)
+		hashes[i] = h
+	}
+	blobs, err := ReadPack(bytes.NewReader(pack.Bytes()), nil)
+	failIfErrNow(t, err)
+	reader := NewStore(blobs, newWidth[int]())
+	for i, h := range hashes {
+		loaded, err := reader.Load(h)
+		failIfErrNow(t, err)
+		failIfNot(t, same(loaded.ToSlice(), trees[i].ToSlice()))
+	}
+	_, err = reader.Save(lazyTree(10))
+	failIfNot(t, errors.Is(err, ErrUnsupported))
+	_, err = reader.Sweep(hashes[1])
+	failIfErrNow(t, err)
+	var compacted bytes.Buffer
+	_, err = blobs.WriteTo(&compacted)
+	failIfErrNow(t, err)
+	failIfNot(t, compacted.Len() < pack.Len())
+	blobs, err = ReadPack(&compacted, nil)
+	failIfErrNow(t, err)
+	loaded, err := NewStore(blobs, newWidth[int]()).Load(hashes[1])
+	failIfErrNow(t, err)
+	failIfNot(t, same(loaded.ToSlice(), []int{1}))
+}
+
+func TestStoreHashIsStable(t *testing.T) {
+	// gob numbers types in the order a process first uses them, which must
+	// not leak into the hashes
+	type unrelated struct{ A, B string }
+	failIfErrNow(t, gob.NewEncoder(io.Discard).Encode(unrelated{"a", "b"}))
+	var pack bytes.Buffer
+	h, err := NewStore(NewPackStore(&pack), newWidth[int]()).Save(newTree(1, 2, 3, 4, 5, 6, 7, 8, 9, 10))
+	failIfErrNow(t, err)
+	if h.String() != "0f68e52c54db58b7f4a824390ec991e14e602d260aef7911d9ccadc11fc9841b" {
+		t.Fatalf("unexpected hash %s", h)
+	}
+}
@@ -0,0 +1,417 @@
+package lazyfingertree
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+)
+
+var ErrFrozen = fmt.Errorf("%w, builder was made persistent", ErrFingerTree)
+
+// A Builder is a mutable tree for batches of edits. It does not change the
+// digits of its trees in place, the trees stay persistent. Instead, values
+// added to either end collect in buffers that the builder owns and changes in
+// place, so adding a value does not allocate a new version of the tree.
+// Removing a value that sits at the bottom of a buffer moves half of that
+// buffer to the next one, so removing values one at a time stays amortized
+// O(1). Persistent builds the buffers into trees in linear time and joins
+// them to the rest. After that the builder is frozen and using it panics with
+// ErrFrozen.
+//
+// Insert, Delete, and Replace edit the middle of the tree at a gap. The
+// builder splits its tree at the first edit and buffers the values on both
+// sides of the gap, so later edits near the gap, like replaying an editor's
+// log, move values between the buffers instead of splitting and joining
+// trees. An edit far from the gap builds the gap's buffers into the tree and
+// splits it again, which costs O(log n) plus the size of those buffers.
+//
+// Every buffer keeps running measures so Measure is O(1).
+//
+// A Builder is not safe for concurrent use.
+type Builder[MS Measurer[V, M], V, M any] struct {
+	measurer Measurer[V, M]
+	// the values are front, left, before, after, right, and back, in order
+	front  buffer[V, M]
+	left   fingerTree[V, M]
+	before buffer[V, M]
+	after  buffer[V, M]
+	right  fingerTree[V, M]
+	back   buffer[V, M]
+	frozen bool
+}
+
+// A buffer is a stack of values with the measures of the values from the
+// bottom of the stack to each value. A reversed buffer's values are in
+// reverse order, the top of the stack comes first in the tree.
+type buffer[V, M any] struct {
+	values   []V
+	sums     []M
+	reversed bool
+}
+
+func (b *buffer[V, M]) len() int {
+	return len(b.values)
+}
+
+func (b *buffer[V, M]) push(meas Measurer[V, M], value V) {
+	m := meas.Measure(value)
+	if len(b.sums) > 0 && b.reversed {
+		m = meas.Sum(m, b.sums[len(b.sums)-1])
+	} else if len(b.sums) > 0 {
+		m = meas.Sum(b.sums[len(b.sums)-1], m)
+	}
+	b.values = append(b.values, value)
+	b.sums = append(b.sums, m)
+}
+
+func (b *buffer[V, M]) top() V {
+	return b.values[len(b.values)-1]
+}
+
+func (b *buffer[V, M]) pop() V {
+	v := b.top()
+	b.values = b.values[:len(b.values)-1]
+	b.sums = b.sums[:len(b.sums)-1]
+	return v
+}
+
+func (b *buffer[V, M]) measure(meas Measurer[V, M]) M {
+	if len(b.sums) == 0 {
+		return meas.Identity()
+	}
+	return b.sums[len(b.sums)-1]
+}
+
+// The value that comes first in the tree
+func (b *buffer[V, M]) first() V {
+	if b.reversed {
+		return b.top()
+	}
+	return b.values[0]
+}
+
+// The value that comes last in the tree
+func (b *buffer[V, M]) last() V {
+	if b.reversed {
+		return b.values[0]
+	}
+	return b.top()
+}
+
+// Remove the n values at the bottom of the stack and return them, bottom
+// first. This remeasures the rest of the values.
+func (b *buffer[V, M]) dropBottom(meas Measurer[V, M], n int) []V {
+	bottom := slices.Clone(b.values[:n])
+	rest := b.values[:copy(b.values, b.values[n:])]
+	b.values = rest[:0]
+	b.sums = b.sums[:0]
+	for _, v := range rest {
+		b.push(meas, v)
+	}
+	return bottom
+}
+
+// Empty the buffer, returning a tree of its values
+func (b *buffer[V, M]) take(meas Measurer[V, M]) fingerTree[V, M] {
+	if b.reversed {
+		slices.Reverse(b.values)
+	}
+	tree := fromArray(meas, b.values)
+	b.values = nil
+	b.sums = nil
+	return tree
+}
+
+// Return a builder for an empty tree
+func NewBuilder[MS Measurer[V, M], V, M any](measurer MS) *Builder[MS, V, M] {
+	return FingerTree[MS, V, M]{f: newEmptyTree[V, M](measurer)}.Transient()
+}
+
+// Return a builder that starts with the tree's values. The tree is not
+// changed.
+func (t FingerTree[MS, V, M]) Transient() *Builder[MS, V, M] {
+	meas := t.measurerOrZero()
+	tree := t.forward()
+	if tree == nil {
+		tree = newEmptyTree(meas)
+	}
+	return &Builder[MS, V, M]{
+		measurer: meas,
+		front:    buffer[V, M]{reversed: true},
+		left:     tree,
+		after:    buffer[V, M]{reversed: true},
+		right:    newEmptyTree(meas),
+	}
+}
+
+func (b *Builder[MS, V, M]) check(op string) {
+	if b.frozen {
+		panic(fmt.Errorf("%w: cannot call %s", ErrFrozen, op))
+	}
+}
+
+func (b *Builder[MS, V, M]) AddFirst(value V) {
+	b.check("AddFirst")
+	b.front.push(b.measurer, value)
+}
+
+func (b *Builder[MS, V, M]) AddLast(value V) {
+	b.check("AddLast")
+	b.back.push(b.measurer, value)
+}
+
+func (b *Builder[MS, V, M]) AppendSlice(values []V) {
+	b.check("AppendSlice")
+	for _, v := range values {
+		b.AddLast(v)
+	}
+}
+
+// Add the tree's values to the end
+func (b *Builder[MS, V, M]) Concat(other FingerTree[MS, V, M]) {
+	b.check("Concat")
+	b.right = b.right.Concat(b.back.take(b.measurer))
+	if other.f != nil {
+		b.right = b.right.Concat(other.forward())
+	}
+}
+
+// Remove the first value, panics if the builder is empty
+func (b *Builder[MS, V, M]) RemoveFirst() {
+	b.check("RemoveFirst")
+	switch {
+	case b.front.len() > 0:
+		b.front.pop()
+	case !isEmpty(b.left):
+		b.left = b.left.RemoveFirst()
+	case b.before.len() > 0:
+		// the first value is at the bottom of the stack
+		b.spill(&b.before, &b.front)
+		b.front.pop()
+	case b.after.len() > 0:
+		b.after.pop()
+	case !isEmpty(b.right):
+		b.right = b.right.RemoveFirst()
+	case b.back.len() > 0:
+		b.spill(&b.back, &b.after)
+		b.after.pop()
+	default:
+		panic(fmt.Errorf("%w: cannot call RemoveFirst", ErrEmptyTree))
+	}
+}
+
+// Remove the last value, panics if the builder is empty
+func (b *Builder[MS, V, M]) RemoveLast() {
+	b.check("RemoveLast")
+	switch {
+	case b.back.len() > 0:
+		b.back.pop()
+	case !isEmpty(b.right):
+		b.right = b.right.RemoveLast()
+	case b.after.len() > 0:
+		// the last value is at the bottom of the stack
+		b.spill(&b.after, &b.back)
+		b.back.pop()
+	case b.before.len() > 0:
+		b.before.pop()
+	case !isEmpty(b.left):
+		b.left = b.left.RemoveLast()
+	case b.front.len() > 0:
+		b.spill(&b.front, &b.before)
+		b.before.pop()
+	default:
+		panic(fmt.Errorf("%w: cannot call RemoveLast", ErrEmptyTree))
+	}
+}
+
+// Move the bottom half of from onto to, which must be empty with nothing
+// between it and from's bottom, so from's bottom value is on top of to.
+// Moving half, not all, keeps removing from alternate ends amortized O(1).
+func (b *Builder[MS, V, M]) spill(from, to *buffer[V, M]) {
+	half := from.dropBottom(b.measurer, (from.len()+1)/2)
+	for i := len(half) - 1; i >= 0; i-- {
+		to.push(b.measurer, half[i])
+	}
+}
+
+// Return the first value, panics if the builder is empty
+func (b *Builder[MS, V, M]) PeekFirst() V {
+	b.check("PeekFirst")
+	switch {
+	case b.front.len() > 0:
+		return b.front.first()
+	case !isEmpty(b.left):
+		return b.left.PeekFirst().value
+	case b.before.len() > 0:
+		return b.before.first()
+	case b.after.len() > 0:
+		return b.after.first()
+	case !isEmpty(b.right):
+		return b.right.PeekFirst().value
+	case b.back.len() > 0:
+		return b.back.first()
+	}
+	panic(fmt.Errorf("%w: cannot call PeekFirst", ErrEmptyTree))
+}
+
+// Return the last value, panics if the builder is empty
+func (b *Builder[MS, V, M]) PeekLast() V {
+	b.check("PeekLast")
+	switch {
+	case b.back.len() > 0:
+		return b.back.last()
+	case !isEmpty(b.right):
+		return b.right.PeekLast().value
+	case b.after.len() > 0:
+		return b.after.last()
+	case b.before.len() > 0:
+		return b.before.last()
+	case !isEmpty(b.left):
+		return b.left.PeekLast().value
+	case b.front.len() > 0:
+		return b.front.last()
+	}
+	panic(fmt.Errorf("%w: cannot call PeekLast", ErrEmptyTree))
+}
+
+func (b *Builder[MS, V, M]) IsEmpty() bool {
+	b.check("IsEmpty")
+	return b.front.len() == 0 && isEmpty(b.left) && b.before.len() == 0 &&
+		b.after.len() == 0 && isEmpty(b.right) && b.back.len() == 0
+}
+
+// Return the measure of all the builder's values
+func (b *Builder[MS, V, M]) Measure() M {
+	b.check("Measure")
+	meas := b.measurer
+	m := meas.Sum(b.front.measure(meas), b.left.measurement())
+	m = meas.Sum(m, b.before.measure(meas))
+	m = meas.Sum(m, b.after.measure(meas))
+	m = meas.Sum(m, b.right.measurement())
+	return meas.Sum(m, b.back.measure(meas))
+}
+
+// Insert a value before the first value where the predicate holds, or at the
+// end if it never holds
+func (b *Builder[MS, V, M]) Insert(pred Predicate[M], value V) {
+	b.check("Insert")
+	if !pred(b.Measure()) {
+		b.AddLast(value)
+		return
+	}
+	b.seek(pred)
+	b.before.push(b.measurer, value)
+}
+
+// Delete the first value where the predicate holds and return whether there
+// was one
+func (b *Builder[MS, V, M]) Delete(pred Predicate[M]) bool {
+	b.check("Delete")
+	if !pred(b.Measure()) {
+		return false
+	}
+	b.seek(pred)
+	if b.after.len() > 0 {
+		b.after.pop()
+	} else {
+		b.right = b.right.RemoveFirst()
+	}
+	return true
+}
+
+// Replace the first value where the predicate holds and return whether there
+// was one
+func (b *Builder[MS, V, M]) Replace(pred Predicate[M], value V) bool {
+	b.check("Replace")
+	if !b.Delete(pred) {
+		return false
+	}
+	b.after.push(b.measurer, value)
+	return true
+}
+
+// How many values the gap moves into or out of the trees one at a time before
+// the builder splits instead
+const gapReach = 32
+
+// Move the gap to just before the first value where the predicate holds,
+// which must exist. That value is then the first one after the gap.
+func (b *Builder[MS, V, M]) seek(pred Predicate[M]) {
+	meas := b.measurer
+	start := meas.Sum(b.front.measure(meas), b.left.measurement())
+	beforeEnd := meas.Sum(start, b.before.measure(meas))
+	if pred(beforeEnd) && !pred(start) {
+		// the value is in the buffer before the gap
+		i := sort.Search(b.before.len(), func(i int) bool {
+			return pred(meas.Sum(start, b.before.sums[i]))
+		})
+		for b.before.len() > i {
+			b.after.push(meas, b.before.pop())
+		}
+		return
+	} else if pred(beforeEnd) && !pred(b.front.measure(meas)) {
+		// the value is in the left tree, look for it near the gap
+		for b.before.len() > 0 {
+			b.after.push(meas, b.before.pop())
+		}
+		for range gapReach {
+			rest := b.left.RemoveLast()
+			b.after.push(meas, b.left.PeekLast().value)
+			b.left = rest
+			if !pred(meas.Sum(b.front.measure(meas), rest.measurement())) {
+				return
+			}
+		}
+	} else if !pred(beforeEnd) {
+		// the value is after the gap, look for it near the gap
+		m := beforeEnd
+		for b.after.len() > 0 {
+			next := meas.Sum(m, meas.Measure(b.after.top()))
+			if pred(next) {
+				return
+			}
+			b.before.push(meas, b.after.pop())
+			m = next
+		}
+		for range gapReach {
+			if isEmpty(b.right) {
+				break
+			}
+			v := b.right.PeekFirst().value
+			next := meas.Sum(m, meas.Measure(v))
+			if pred(next) {
+				return
+			}
+			b.before.push(meas, v)
+			b.right = b.right.RemoveFirst()
+			m = next
+		}
+	}
+	front := b.front.measure(meas)
+	b.left = b.left.Concat(b.before.take(meas)).Concat(b.after.take(meas)).Concat(b.right)
+	b.right = newEmptyTree(meas)
+	if pred(front) || !pred(meas.Sum(front, b.left.measurement())) {
+		// the value is in the front or back buffer
+		b.left, b.right = b.flush().Split(pred)
+		return
+	}
+	left, value, right := b.left.splitTree(pred, front)
+	b.left, b.right = left, right.AddFirst(value)
+}
+
+// build the buffers into one tree
+func (b *Builder[MS, V, M]) flush() fingerTree[V, M] {
+	meas := b.measurer
+	return b.front.take(meas).Concat(b.left).
+		Concat(b.before.take(meas)).
+		Concat(b.after.take(meas)).
+		Concat(b.right).
+		Concat(b.back.take(meas))
+}
+
+// Return the tree and freeze the builder
+func (b *Builder[MS, V, M]) Persistent() FingerTree[MS, V, M] {
+	b.check("Persistent")
+	b.frozen = true
+	return FingerTree[MS, V, M]{f: b.flush()}
+}
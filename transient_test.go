@@ -0,0 +1,149 @@
+package lazyfingertree
+
+import (
+	"errors"
+	"math/rand"
+	"slices"
+	"testing"
+)
+
+func TestBuilder(t *testing.T) {
+	base := lazyTree(100)
+	b := base.Transient()
+	for i := range 1000 {
+		b.AddLast(100 + i)
+		b.AddFirst(-1 - i)
+	}
+	failIfNot(t, b.Measure() == 2100 && b.PeekFirst() == -1000 && b.PeekLast() == 1099)
+	b.RemoveFirst()
+	b.RemoveLast()
+	failIfNot(t, b.Measure() == 2098)
+	b.Concat(newTree(7, 8))
+	b.AppendSlice([]int{9})
+	tree := b.Persistent()
+	expected := make([]int, 0, 2101)
+	for i := -999; i < 1099; i++ {
+		expected = append(expected, i)
+	}
+	expected = append(expected, 7, 8, 9)
+	failIfNot(t, same(tree.ToSlice(), expected))
+	failIfNot(t, tree.Measure() == len(expected) && base.Measure() == 100)
+	_, err := try(func() bool {
+		b.AddLast(1)
+		return true
+	})
+	failIfNot(t, errors.Is(err, ErrFrozen))
+}
+
+func TestBuilderBuffersOnly(t *testing.T) {
+	b := NewBuilder[width[int, int]](newWidth[int]())
+	failIfNot(t, b.IsEmpty())
+	b.AddFirst(2)
+	b.AddFirst(1)
+	failIfNot(t, b.PeekLast() == 2)
+	b.RemoveLast()
+	failIfNot(t, b.PeekLast() == 1 && b.Measure() == 1)
+	b.RemoveFirst()
+	failIfNot(t, b.IsEmpty())
+	_, err := try(func() bool {
+		b.RemoveFirst()
+		return true
+	})
+	failIfNot(t, errors.Is(err, ErrEmptyTree))
+	b.AddLast(3)
+	failIfNot(t, b.PeekFirst() == 3)
+	failIfNot(t, same(b.Persistent().ToSlice(), []int{3}))
+}
+
+func TestBuilderEdits(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	base := lazyTree(1000)
+	b := base.Transient()
+	expected := base.ToSlice()
+	pos := 500
+	for i := range 5000 {
+		// mostly edit near the last edit, sometimes jump
+		if rng.Intn(50) == 0 {
+			pos = rng.Intn(len(expected) + 1)
+		} else {
+			pos = min(max(0, pos+rng.Intn(7)-3), len(expected))
+		}
+		switch rng.Intn(5) {
+		case 0, 1:
+			b.Insert(after(pos), -i)
+			expected = slices.Insert(expected, pos, -i)
+		case 2:
+			failIfNot(t, b.Delete(after(pos)) == (pos < len(expected)))
+			if pos < len(expected) {
+				expected = slices.Delete(expected, pos, pos+1)
+			}
+		case 3:
+			failIfNot(t, b.Replace(after(pos), -i) == (pos < len(expected)))
+			if pos < len(expected) {
+				expected[pos] = -i
+			}
+		default:
+			if rng.Intn(2) == 0 {
+				b.AddFirst(-i)
+				expected = slices.Insert(expected, 0, -i)
+			} else if len(expected) > 0 {
+				b.RemoveLast()
+				expected = expected[:len(expected)-1]
+			}
+		}
+		failIfNot(t, b.Measure() == len(expected))
+		if len(expected) > 0 {
+			failIfNot(t, b.PeekFirst() == expected[0] && b.PeekLast() == expected[len(expected)-1])
+		}
+	}
+	tree := b.Persistent()
+	failIfNot(t, same(tree.ToSlice(), expected))
+	failIfErrNow(t, tree.Validate())
+	failIfNot(t, base.Measure() == 1000)
+}
+
+func TestBuilderMeasureIncremental(t *testing.T) {
+	calls := 0
+	b := NewBuilder[countingWidth](countingWidth{&calls})
+	for i := range 10000 {
+		b.AddLast(i)
+		b.AddFirst(i)
+	}
+	calls = 0
+	for range 10000 {
+		b.RemoveFirst()
+		b.RemoveLast()
+		b.Measure()
+	}
+	failIfNot(t, b.IsEmpty())
+	if calls > 0 {
+		t.Fatalf("measured %d values to keep the measure after removals", calls)
+	}
+}
+
+func TestBuilderRemovesFromBuffers(t *testing.T) {
+	for _, first := range []bool{false, true} {
+		calls := 0
+		b := NewBuilder[countingWidth](countingWidth{&calls})
+		for i := range 1000 {
+			if first {
+				b.AddFirst(999 - i)
+			} else {
+				b.AddLast(i)
+			}
+		}
+		calls = 0
+		for i := range 500 {
+			failIfNot(t, b.PeekFirst() == i && b.PeekLast() == 999-i)
+			b.RemoveFirst()
+			b.RemoveLast()
+			// the values stay in the buffers instead of being built into trees
+			failIfNot(t, isEmpty(b.left) && isEmpty(b.right))
+			failIfNot(t, b.Measure() == 998-2*i)
+		}
+		failIfNot(t, b.IsEmpty())
+		if calls > 2000 {
+			t.Fatalf("measured %d values to remove 1000", calls)
+		}
+	}
+}
@@ -0,0 +1,157 @@
+package lazyfingertree
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+var ErrInvalid = fmt.Errorf("%w, invalid tree", ErrFingerTree)
+
+// Check the tree's internal structure, returning an error that wraps
+// [ErrInvalid] and gives the path to the first problem. Digits must have 1 to
+// 4 items, nodes must have 2 or 3 children, every value must be at the same
+// depth, and every cached measure must equal the sum of the measures under it.
+// Measures are compared with reflect.DeepEqual, use [ValidateFunc] for
+// measures that need another comparison, like floats that can be NaN.
+//
+// This forces delayed trees and measures every value, so it is O(n). A
+// measurer that changes its arguments or returns shared mutable measures
+// shows up here as a cached measure that no longer matches its contents.
+func (t FingerTree[MS, V, M]) Validate() error {
+	return t.ValidateFunc(func(a, b M) bool { return reflect.DeepEqual(a, b) })
+}
+
+// Like [FingerTree.Validate] but comparing measures with eq
+func (t FingerTree[MS, V, M]) ValidateFunc(eq func(a, b M) bool) error {
+	if t.f == nil {
+		return nil
+	}
+	v := &validator[V, M]{measurer: measurerFor(t.f), eq: eq}
+	if v.measurer == nil {
+		return fmt.Errorf("%w: tree has no measurer", ErrInvalid)
+	}
+	_, err := v.tree(t.f, 0)
+	return err
+}
+
+// A validator returns the recomputed measure of each part it checks. Path
+// names the parts from the root down to the current one.
+type validator[V, M any] struct {
+	measurer Measurer[V, M]
+	eq       func(a, b M) bool
+	path     []string
+}
+
+func (v *validator[V, M]) fail(format string, args ...any) error {
+	var path strings.Builder
+	path.WriteString("tree")
+	for _, name := range v.path {
+		if !strings.HasPrefix(name, "[") {
+			path.WriteString(".")
+		}
+		path.WriteString(name)
+	}
+	return fmt.Errorf("%w at %s: %s", ErrInvalid, path.String(), fmt.Sprintf(format, args...))
+}
+
+func (v *validator[V, M]) push(name string) {
+	v.path = append(v.path, name)
+}
+
+func (v *validator[V, M]) pop() {
+	v.path = v.path[:len(v.path)-1]
+}
+
+func (v *validator[V, M]) checkMeasure(what string, cached, actual M) error {
+	if !v.eq(cached, actual) {
+		return v.fail("cached %s measure is %v but its contents measure %v", what, cached, actual)
+	}
+	return nil
+}
+
+// Depth is the height of the nodes the tree holds, 0 for values
+func (v *validator[V, M]) tree(t fingerTree[V, M], depth int) (M, error) {
+	switch t := force(t).(type) {
+	case *emptyTree[V, M]:
+		return t._measurement, v.checkMeasure("empty tree", t._measurement, v.measurer.Identity())
+	case *singleTree[V, M]:
+		v.push("single")
+		defer v.pop()
+		m, err := v.elem(t.value, depth)
+		if err != nil {
+			return m, err
+		}
+		return m, v.checkMeasure("single tree", t.measurement(), m)
+	case *deepTree[V, M]:
+		left, err := v.digit("left", t.left, depth)
+		if err != nil {
+			return left, err
+		}
+		v.push("mid")
+		mid, err := v.tree(t.mid, depth+1)
+		v.pop()
+		if err != nil {
+			return mid, err
+		}
+		right, err := v.digit("right", t.right, depth)
+		if err != nil {
+			return right, err
+		}
+		m := v.measurer.Sum(v.measurer.Sum(left, mid), right)
+		return m, v.checkMeasure("deep tree", t.measurement(), m)
+	case nil:
+		return v.measurer.Identity(), v.fail("missing tree")
+	default:
+		return v.measurer.Identity(), v.fail("unknown tree type %T", t)
+	}
+}
+
+func (v *validator[V, M]) digit(name string, d *digit[V, M], depth int) (M, error) {
+	v.push(name)
+	defer v.pop()
+	if d == nil {
+		return v.measurer.Identity(), v.fail("missing digit")
+	} else if d.size < 1 || d.size > 4 {
+		return v.measurer.Identity(), v.fail("digit has %d items, expected 1 to 4", d.size)
+	}
+	m, err := v.elems(d.elems(), depth)
+	if err != nil {
+		return m, err
+	}
+	return m, v.checkMeasure("digit", d.getMeasurement(), m)
+}
+
+func (v *validator[V, M]) elems(items []elem[V, M], depth int) (M, error) {
+	m := v.measurer.Identity()
+	for i, item := range items {
+		v.push(fmt.Sprintf("[%d]", i))
+		im, err := v.elem(item, depth)
+		v.pop()
+		if err != nil {
+			return m, err
+		}
+		m = v.measurer.Sum(m, im)
+	}
+	return m, nil
+}
+
+func (v *validator[V, M]) elem(e elem[V, M], depth int) (M, error) {
+	if depth == 0 {
+		if e.node != nil {
+			return v.measurer.Identity(), v.fail("expected a value but found a node")
+		}
+		return v.measurer.Measure(e.value), nil
+	} else if e.node == nil {
+		return v.measurer.Identity(), v.fail("expected a node of height %d but found a value", depth)
+	}
+	n := e.node
+	if n.size < 2 || n.size > 3 {
+		return v.measurer.Identity(), v.fail("node has %d children, expected 2 or 3", n.size)
+	}
+	m, err := v.elems(n.elems(), depth-1)
+	if err != nil {
+		return m, err
+	}
+	return m, v.checkMeasure("node", n.measurement(), m)
+}
@@ -0,0 +1,107 @@
+package lazyfingertree
+
+import (
+	"errors"
+	"math"
+	"strings"
+	"testing"
+)
+
+// Appends to the shared backing array of its left argument, so summing can
+// change measures that trees have already cached
+type appendingMeasurer struct{}
+
+func (appendingMeasurer) Identity() []int     { return make([]int, 0, 1024) }
+func (appendingMeasurer) Measure(v int) []int { return append(make([]int, 0, 1024), v) }
+func (appendingMeasurer) Sum(a, b []int) []int {
+	a = append(a, b...)
+	if len(a) > 0 {
+		a[0]++
+	}
+	return a
+}
+
+func ints(n int) []int {
+	result := make([]int, n)
+	for i := range result {
+		result[i] = i
+	}
+	return result
+}
+
+func failIfValid(t *testing.T, tree FingerTree[width[int, int], int, int], path string) {
+	t.Helper()
+	err := tree.Validate()
+	if !errors.Is(err, ErrInvalid) {
+		t.Fatalf("expected an invalid tree error but got %v", err)
+	} else if !strings.Contains(err.Error(), " at "+path+":") {
+		t.Fatalf("expected the error to be at %s but got %v", path, err)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	failIfErrNow(t, FingerTree[width[int, int], int, int]{}.Validate())
+	for _, size := range []int{0, 1, 2, 5, 9, 27, 100, 1000} {
+		tree := newTree(ints(size)...)
+		failIfErrNow(t, tree.Validate())
+		failIfErrNow(t, tree.Reverse().Validate())
+		if size > 0 {
+			failIfErrNow(t, lazyTree(size).Validate())
+			left, right := tree.Split(func(w int) bool { return w > size/3 })
+			failIfErrNow(t, left.Validate())
+			failIfErrNow(t, right.Validate())
+			failIfErrNow(t, right.Concat(left).AddFirst(-1).RemoveLast().Validate())
+		}
+	}
+	mutated := FromArray(appendingMeasurer{}, ints(50))
+	mutated.Measure()
+	if err := mutated.Validate(); !errors.Is(err, ErrInvalid) {
+		t.Fatalf("expected a measurer that changes its arguments to corrupt the tree but got %v", err)
+	}
+}
+
+func TestValidateCorrupt(t *testing.T) {
+	meas := newWidth[int]()
+	leaves := func(values ...int) []elem[int, int] {
+		result := make([]elem[int, int], len(values))
+		for i, v := range values {
+			result[i] = leaf[int, int](v)
+		}
+		return result
+	}
+	deep := func(left *digit[int, int], mid fingerTree[int, int], right *digit[int, int]) FingerTree[width[int, int], int, int] {
+		return wrapTree[width[int, int], int, int](newDeepTree(meas, left, mid, right))
+	}
+	good := newDigit(meas, leaves(1, 2)...)
+	empty := newEmptyTree(meas)
+	failIfErrNow(t, deep(good, empty, good).Validate())
+	failIfValid(t, deep(&digit[int, int]{}, empty, good), "tree.left")
+	badMeasure := newDigit(meas, leaves(1, 2)...)
+	badMeasure._measurement = 3
+	failIfValid(t, deep(good, empty, badMeasure), "tree.right")
+	// a value where the mid tree should hold nodes
+	failIfValid(t, deep(good, newSingleTree(meas, leaf[int, int](3)), good), "tree.mid.single")
+	short := newNode(meas, leaves(3)...)
+	failIfValid(t, deep(good, newSingleTree(meas, short.asElem()), good), "tree.mid.single")
+	badNode := newNode(meas, leaves(3, 4)...)
+	badNode._measurement = 5
+	mid := newDeepTree(meas, newDigit(meas, newNode(meas, leaves(5, 6)...).asElem()), empty, newDigit(meas, badNode.asElem()))
+	failIfValid(t, deep(good, newDelayed(func() fingerTree[int, int] { return mid }), good), "tree.mid.right[0]")
+	// children at different depths in the same node
+	uneven := newNode(meas, leaf[int, int](5), newNode(meas, leaves(7, 8)...).asElem())
+	failIfValid(t, deep(good, newSingleTree(meas, uneven.asElem()), good), "tree.mid.single[1]")
+}
+
+type floatSum struct{}
+
+func (floatSum) Identity() float64         { return 0 }
+func (floatSum) Measure(v float64) float64 { return v }
+func (floatSum) Sum(a, b float64) float64  { return a + b }
+
+func TestValidateFunc(t *testing.T) {
+	tree := FromArray(floatSum{}, []float64{1, math.NaN(), 2})
+	failIfNot(t, errors.Is(tree.Validate(), ErrInvalid))
+	failIfErrNow(t, tree.ValidateFunc(func(a, b float64) bool {
+		return a == b || math.IsNaN(a) && math.IsNaN(b)
+	}))
+}
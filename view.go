@@ -0,0 +1,180 @@
+package lazyfingertree
+
+import (
+	"iter"
+)
+
+// A View presents a tree as if f had been applied to every value and the
+// results measured with a new measurer. Values are only mapped as they are
+// used, so iterating over part of a view only calls f on that part.
+//
+// Finding values by measure needs the new measures of everything before them.
+// A view made with [MapViewMeasure] derives those from the source tree's
+// measures so Split, Lookup, TakeUntil, and DropUntil stay O(log n) and
+// only map the values they return. A view made with [MapView] has to map and
+// measure the values before the one a search finds, but nothing after it: the
+// mapped tree is built as searches reach it and a node's values are only
+// mapped when a search looks inside it. Each value is mapped at most once and
+// the mapped parts are shared with the views made from it. Measure maps the
+// whole tree.
+type View[MS2 Measurer[V2, M2], V, M, V2, M2 any] struct {
+	// the source values, nil if the view only has the mapped tree
+	src      fingerTree[V, M]
+	f        func(V) V2
+	measurer Measurer[V2, M2]
+	measure  func(M) M2
+	mapped   *lazy[fingerTree[V2, M2]]
+}
+
+// Return a view of t with f applied to each value, measured with measurer.
+func MapView[MS2 Measurer[V2, M2], MS Measurer[V, M], V, M, V2, M2 any](t FingerTree[MS, V, M], f func(V) V2, measurer MS2) View[MS2, V, M, V2, M2] {
+	return MapViewMeasure(t, f, measurer, nil)
+}
+
+// Return a view of t with f applied to each value, measured with measurer.
+// Measure converts measures of t into measures of the view. It must agree
+// with measurer, so measure(m) is the sum of the new measures of the values
+// whose old measures sum to m, like converting a count or a size.
+func MapViewMeasure[MS2 Measurer[V2, M2], MS Measurer[V, M], V, M, V2, M2 any](t FingerTree[MS, V, M], f func(V) V2, measurer MS2, measure func(M) M2) View[MS2, V, M, V2, M2] {
+	src := t.forward()
+	if src == nil {
+		src = newEmptyTree(t.measurerOrZero())
+	}
+	return View[MS2, V, M, V2, M2]{src, f, measurer, measure, &lazy[fingerTree[V2, M2]]{}}
+}
+
+func (v View[MS2, V, M, V2, M2]) withSource(src fingerTree[V, M]) View[MS2, V, M, V2, M2] {
+	return View[MS2, V, M, V2, M2]{src, v.f, v.measurer, v.measure, &lazy[fingerTree[V2, M2]]{}}
+}
+
+func (v View[MS2, V, M, V2, M2]) withMapped(tree fingerTree[V2, M2]) View[MS2, V, M, V2, M2] {
+	mapped := &lazy[fingerTree[V2, M2]]{}
+	mapped.set(tree)
+	return View[MS2, V, M, V2, M2]{nil, v.f, v.measurer, v.measure, mapped}
+}
+
+func (v View[MS2, V, M, V2, M2]) tree() fingerTree[V2, M2] {
+	return v.mapped.get(func() fingerTree[V2, M2] {
+		m := &mirror[V, M, V2, M2]{f: v.f, measurer: v.measurer}
+		return m.tree(v.src)
+	})
+}
+
+// Return whether the view can find values using the source tree's measures
+func (v View[MS2, V, M, V2, M2]) lazyMeasures() bool {
+	return v.src != nil && v.measure != nil
+}
+
+// Return the view as a tree. Its values are mapped as it is used.
+func (v View[MS2, V, M, V2, M2]) Tree() FingerTree[MS2, V2, M2] {
+	return FingerTree[MS2, V2, M2]{f: v.tree()}
+}
+
+func (v View[MS2, V, M, V2, M2]) IsEmpty() bool {
+	if v.src != nil {
+		return isEmpty(v.src)
+	}
+	return isEmpty(v.tree())
+}
+
+// Return the measure of all the view's values
+func (v View[MS2, V, M, V2, M2]) Measure() M2 {
+	if v.lazyMeasures() {
+		return v.measure(v.src.measurement())
+	}
+	return v.tree().measurement()
+}
+
+// Return the first value. This panics if the view is empty.
+func (v View[MS2, V, M, V2, M2]) PeekFirst() V2 {
+	if v.src != nil {
+		return v.f(v.src.PeekFirst().value)
+	}
+	return v.tree().PeekFirst().value
+}
+
+// Return the last value. This panics if the view is empty.
+func (v View[MS2, V, M, V2, M2]) PeekLast() V2 {
+	if v.src != nil {
+		return v.f(v.src.PeekLast().value)
+	}
+	return v.tree().PeekLast().value
+}
+
+func (v View[MS2, V, M, V2, M2]) sourcePredicate(pred Predicate[M2]) Predicate[M] {
+	return func(m M) bool { return pred(v.measure(m)) }
+}
+
+// Split the view like [FingerTree.Split]
+func (v View[MS2, V, M, V2, M2]) Split(pred Predicate[M2]) (View[MS2, V, M, V2, M2], View[MS2, V, M, V2, M2]) {
+	if v.lazyMeasures() {
+		left, right := v.src.Split(v.sourcePredicate(pred))
+		return v.withSource(left), v.withSource(right)
+	}
+	left, right := v.tree().Split(pred)
+	return v.withMapped(left), v.withMapped(right)
+}
+
+// Return all the initial values that do not satisfy the predicate
+func (v View[MS2, V, M, V2, M2]) TakeUntil(pred Predicate[M2]) View[MS2, V, M, V2, M2] {
+	left, _ := v.Split(pred)
+	return left
+}
+
+// Discard all the initial values that do not satisfy the predicate
+func (v View[MS2, V, M, V2, M2]) DropUntil(pred Predicate[M2]) View[MS2, V, M, V2, M2] {
+	_, right := v.Split(pred)
+	return right
+}
+
+// Find a value like [FingerTree.Lookup]. Only the value that is found is mapped.
+func (v View[MS2, V, M, V2, M2]) Lookup(pred Predicate[M2]) (prefix M2, value V2, ok bool) {
+	if v.lazyMeasures() {
+		if isEmpty(v.src) || !pred(v.Measure()) {
+			return prefix, value, false
+		}
+		srcPrefix, item := v.src.lookup(v.sourcePredicate(pred), measurerFor(v.src).Identity())
+		return v.measure(srcPrefix), v.f(item.value), true
+	}
+	return FingerTree[MS2, V2, M2]{f: v.tree()}.Lookup(pred)
+}
+
+// Iterate through the view starting at the beginning
+func (v View[MS2, V, M, V2, M2]) Each(iter IterFunc[V2]) {
+	if v.src != nil {
+		v.src.Each(func(value V) bool { return iter(v.f(value)) })
+		return
+	}
+	v.tree().Each(iter)
+}
+
+func (v View[MS2, V, M, V2, M2]) Seq() iter.Seq[V2] {
+	return func(yield func(V2) bool) {
+		v.Each(yield)
+	}
+}
+
+// Iterate through the view starting at the end
+func (v View[MS2, V, M, V2, M2]) EachReverse(iter IterFunc[V2]) {
+	if v.src != nil {
+		v.src.EachReverse(func(value V) bool { return iter(v.f(value)) })
+		return
+	}
+	v.tree().EachReverse(iter)
+}
+
+func (v View[MS2, V, M, V2, M2]) SeqReverse() iter.Seq[V2] {
+	return func(yield func(V2) bool) {
+		v.EachReverse(yield)
+	}
+}
+
+// Return a slice of all the view's values
+func (v View[MS2, V, M, V2, M2]) ToSlice() []V2 {
+	var result []V2
+	v.Each(func(value V2) bool {
+		result = append(result, value)
+		return true
+	})
+	return result
+}
@@ -0,0 +1,96 @@
+package lazyfingertree
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+type countStrings struct{}
+
+func (countStrings) Identity() int        { return 0 }
+func (countStrings) Measure(s string) int { return 1 }
+func (countStrings) Sum(a int, b int) int { return a + b }
+
+func TestMapViewMeasure(t *testing.T) {
+	calls := 0
+	row := func(v int) string {
+		calls++
+		return fmt.Sprintf("row %d", v)
+	}
+	view := MapViewMeasure(lazyTree(100000), row, countStrings{}, func(m int) int { return m })
+	failIfNot(t, view.Measure() == 100000)
+	prefix, value, ok := view.Lookup(func(m int) bool { return m > 5000 })
+	failIfNot(t, ok && prefix == 5000 && value == "row 5000")
+	window := view.DropUntil(func(m int) bool { return m > 50000 }).TakeUntil(func(m int) bool { return m > 10 })
+	failIfNot(t, window.Measure() == 10 && window.PeekFirst() == "row 50000" && window.PeekLast() == "row 50009")
+	failIfNot(t, len(window.ToSlice()) == 10)
+	if calls > 20 {
+		t.Fatalf("mapped %d values", calls)
+	}
+	failIfNot(t, window.Tree().Measure() == 10)
+}
+
+func TestMapView(t *testing.T) {
+	calls := 0
+	view := MapView(lazyTree(100000), func(v int) string {
+		calls++
+		return fmt.Sprint(v)
+	}, byteLen{})
+	for v := range view.Seq() {
+		if v == "9" {
+			break
+		}
+	}
+	failIfNot(t, calls == 10)
+	calls = 0
+	left, right := view.Split(func(m int) bool { return m > 10 })
+	// only the values before the split point and the nodes on its path are
+	// mapped
+	if calls > 100 {
+		t.Fatalf("mapped %d values to split", calls)
+	}
+	failIfNot(t, same(left.ToSlice(), []string{"0", "1", "2", "3", "4", "5", "6", "7", "8", "9"}))
+	failIfNot(t, right.PeekFirst() == "10" && right.Measure() == view.Measure()-10)
+	mapped := calls
+	_, value, ok := view.Lookup(func(m int) bool { return m > 12 })
+	failIfNot(t, ok && value == "11" && calls == mapped)
+	failIfNot(t, MapView(newTree[int](), func(v int) string { return fmt.Sprint(v) }, byteLen{}).IsEmpty())
+}
+
+func TestMapViewMiddle(t *testing.T) {
+	for _, tree := range []FingerTree[width[int, int], int, int]{lazyTree(100000), newTree(ints(100000)...)} {
+		var calls atomic.Int64
+		view := MapView(tree, func(v int) string {
+			calls.Add(1)
+			return fmt.Sprint(v)
+		}, countStrings{})
+		prefix, value, ok := view.Lookup(func(m int) bool { return m > 50000 })
+		failIfNot(t, ok && prefix == 50000 && value == "50000")
+		// the new measures of the values before the one found are needed but
+		// none of the values after it are mapped
+		if calls.Load() > 50100 {
+			t.Fatalf("mapped %d values to find value 50000", calls.Load())
+		}
+		calls.Store(0)
+		left, right := view.Split(func(m int) bool { return m > 60000 })
+		failIfNot(t, left.PeekLast() == "59999" && right.PeekFirst() == "60000")
+		if calls.Load() > 10100 {
+			t.Fatalf("mapped %d values to split at 60000 after finding 50000", calls.Load())
+		}
+		// mapped nodes are built once for concurrent searches
+		var wg sync.WaitGroup
+		for i := range 8 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				pos := 70000 + i*1000
+				_, value, ok := view.Lookup(func(m int) bool { return m > pos })
+				failIfNot(t, ok && value == fmt.Sprint(pos))
+			}()
+		}
+		wg.Wait()
+		failIfNot(t, view.Measure() == 100000 && calls.Load() <= 100000)
+	}
+}
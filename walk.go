@@ -0,0 +1,329 @@
+package lazyfingertree
+
+import (
+	"iter"
+)
+
+// Range iteration descends once to the first value and then walks forward
+// in place instead of building trees with Split. Subtrees that lie entirely
+// inside the range are iterated without measuring their values.
+//
+// Reversed trees are walked from the end of the low-level tree with suffix
+// measures, like splitTreeRight.
+
+// Return the values from the first one where pred holds to the end, like
+// iterating over the second tree from [Split]
+func (t FingerTree[MS, V, M]) SeqFrom(pred Predicate[M]) iter.Seq[V] {
+	return t.SeqRange(pred, nil)
+}
+
+// Return the values from the first one where from holds up to, but not
+// including, the first one where to holds. Both predicates see the measure of
+// the values from the start of the tree, so this iterates over the same
+// values as t.TakeUntil(to).DropUntil(from). A nil predicate means the start or
+// the end of the tree.
+func (t FingerTree[MS, V, M]) SeqRange(from, to Predicate[M]) iter.Seq[V] {
+	return func(yield func(V) bool) {
+		if t.f == nil {
+			return
+		}
+		w := &rangeWalker[V, M]{measurer: measurerFor(t.f), reverse: t.reversed, start: from, stop: to, yield: yield}
+		w.tree(t.f, w.measurer.Identity())
+	}
+}
+
+// Return the value where pred first holds and then the values before it,
+// from last to first. If pred never holds this returns all of the values from
+// last to first.
+func (t FingerTree[MS, V, M]) SeqReverseFrom(pred Predicate[M]) iter.Seq[V] {
+	return func(yield func(V) bool) {
+		if t.f == nil || isEmpty(t.f) {
+			return
+		} else if !t.holds(pred) {
+			t.eachReverse(yield)
+			return
+		} else if t.reversed {
+			reverseFromRight(measurerFor(t.f), t.f, pred, measurerFor(t.f).Identity(), yield)
+			return
+		}
+		reverseFrom(measurerFor(t.f), t.f, pred, measurerFor(t.f).Identity(), yield)
+	}
+}
+
+// A rangeWalker yields the values between the first one where start holds and
+// the first one where stop holds. Start is cleared once the first value is
+// yielded and nil predicates are unbounded. A reverse walker goes from the
+// end of the tree to the start and its measures are suffixes.
+type rangeWalker[V, M any] struct {
+	measurer    Measurer[V, M]
+	reverse     bool
+	start, stop Predicate[M]
+	yield       func(V) bool
+}
+
+func (w *rangeWalker[V, M]) unbounded() bool {
+	return w.start == nil && w.stop == nil
+}
+
+// Add the measure of an item to the measure of the values already walked
+func (w *rangeWalker[V, M]) add(walked, m M) M {
+	if w.reverse {
+		return w.measurer.Sum(m, walked)
+	}
+	return w.measurer.Sum(walked, m)
+}
+
+func (w *rangeWalker[V, M]) each(e elem[V, M]) bool {
+	if w.reverse {
+		return iterateEachReverse(e, w.yield)
+	}
+	return iterateEach(e, w.yield)
+}
+
+// Walk the tree, returning the measure of the values up to the end of it
+// and whether to continue. The measure is not kept once the walk is
+// unbounded.
+func (w *rangeWalker[V, M]) tree(t fingerTree[V, M], walked M) (M, bool) {
+	switch t := force(t).(type) {
+	case *singleTree[V, M]:
+		return w.item(t.value, walked)
+	case *deepTree[V, M]:
+		if w.unbounded() && w.reverse {
+			return walked, t.EachReverse(w.yield)
+		} else if w.unbounded() {
+			return walked, t.Each(w.yield)
+		}
+		first, last := t.left, t.right
+		if w.reverse {
+			first, last = last, first
+		}
+		var ok bool
+		if walked, ok = w.items(first.elems(), walked); !ok {
+			return walked, false
+		} else if walked, ok = w.tree(t.mid, walked); !ok {
+			return walked, false
+		}
+		return w.items(last.elems(), walked)
+	}
+	return walked, true
+}
+
+func (w *rangeWalker[V, M]) items(items []elem[V, M], walked M) (M, bool) {
+	for i := range items {
+		item := items[i]
+		if w.reverse {
+			item = items[len(items)-1-i]
+		}
+		var ok bool
+		if walked, ok = w.item(item, walked); !ok {
+			return walked, false
+		}
+	}
+	return walked, true
+}
+
+func (w *rangeWalker[V, M]) item(e elem[V, M], walked M) (M, bool) {
+	if w.unbounded() {
+		return walked, w.each(e)
+	}
+	m := w.add(walked, e.measure(w.measurer))
+	if w.start != nil && !w.start(m) {
+		// the item is before the range
+		return m, true
+	} else if e.node == nil {
+		if w.stop != nil && w.stop(m) {
+			return m, false
+		}
+		w.start = nil
+		return m, w.yield(e.value)
+	} else if (w.start != nil && !w.start(walked)) || (w.stop != nil && w.stop(m)) {
+		// the range starts or ends inside the node
+		return w.items(e.node.elems(), walked)
+	}
+	w.start = nil
+	return m, w.each(e)
+}
+
+// Yield the element where pred first holds, or the last one, and then the
+// ones before it in reverse
+func reverseFrom[V, M any](meas Measurer[V, M], t fingerTree[V, M], pred Predicate[M], prefix M, yield func(V) bool) bool {
+	switch t := force(t).(type) {
+	case *singleTree[V, M]:
+		return reverseFromElems(meas, []elem[V, M]{t.value}, pred, prefix, yield)
+	case *deepTree[V, M]:
+		leftMeasure, inLeft := holdsDigit(meas, t.left, pred, prefix)
+		if inLeft {
+			return reverseFromElems(meas, t.left.elems(), pred, prefix, yield)
+		}
+		midMeasure, inMid := holds(meas, t.mid, pred, leftMeasure)
+		if inMid {
+			return reverseFrom(meas, t.mid, pred, leftMeasure, yield) && t.left.EachReverse(yield)
+		}
+		return reverseFromElems(meas, t.right.elems(), pred, midMeasure, yield) &&
+			t.mid.EachReverse(yield) &&
+			t.left.EachReverse(yield)
+	}
+	return true
+}
+
+func reverseFromElems[V, M any](meas Measurer[V, M], items []elem[V, M], pred Predicate[M], prefix M, yield func(V) bool) bool {
+	prefix, i := findElem(meas, items, pred, prefix)
+	if items[i].node != nil {
+		if !reverseFromElems(meas, items[i].node.elems(), pred, prefix, yield) {
+			return false
+		}
+	} else if !yield(items[i].value) {
+		return false
+	}
+	for i--; i >= 0; i-- {
+		if !iterateEachReverse(items[i], yield) {
+			return false
+		}
+	}
+	return true
+}
+
+// Like reverseFrom for a reversed tree: find the element where pred first
+// holds on the suffix measures and yield it and then the ones after it
+func reverseFromRight[V, M any](meas Measurer[V, M], t fingerTree[V, M], pred Predicate[M], suffix M, yield func(V) bool) bool {
+	switch t := force(t).(type) {
+	case *singleTree[V, M]:
+		return reverseFromElemsRight(meas, []elem[V, M]{t.value}, pred, suffix, yield)
+	case *deepTree[V, M]:
+		rightMeasure, inRight := holdsDigitRight(meas, t.right, pred, suffix)
+		if inRight {
+			return reverseFromElemsRight(meas, t.right.elems(), pred, suffix, yield)
+		}
+		midMeasure, inMid := holdsRight(meas, t.mid, pred, rightMeasure)
+		if inMid {
+			return reverseFromRight(meas, t.mid, pred, rightMeasure, yield) && t.right.Each(yield)
+		}
+		return reverseFromElemsRight(meas, t.left.elems(), pred, midMeasure, yield) &&
+			t.mid.Each(yield) &&
+			t.right.Each(yield)
+	}
+	return true
+}
+
+func reverseFromElemsRight[V, M any](meas Measurer[V, M], items []elem[V, M], pred Predicate[M], suffix M, yield func(V) bool) bool {
+	suffix, i := findElemRight(meas, items, pred, suffix)
+	if items[i].node != nil {
+		if !reverseFromElemsRight(meas, items[i].node.elems(), pred, suffix, yield) {
+			return false
+		}
+	} else if !yield(items[i].value) {
+		return false
+	}
+	for i++; i < len(items); i++ {
+		if !iterateEach(items[i], yield) {
+			return false
+		}
+	}
+	return true
+}
+
+// Iterate through the tree, passing each value with the measure of the values
+// before it. The measures are built from the cached measures of the tree's
+// digits and nodes where they cover everything before a value, but the other
+// values in a node or digit must be measured again. Nodes hold 2 or 3 values,
+// so about 2/3 of the values are measured.
+func (t FingerTree[MS, V, M]) EachMeasured(f func(prefix M, value V) bool) {
+	if t.reversed {
+		eachMeasuredReverse(measurerFor(t.f), t.f, measurerFor(t.f).Identity(), f)
+		return
+	}
+	eachMeasured(measurerFor(t.f), t.f, measurerFor(t.f).Identity(), f)
+}
+
+// Return the values with the measures of the values before them, see [EachMeasured]
+func (t FingerTree[MS, V, M]) SeqMeasured() iter.Seq2[M, V] {
+	return func(yield func(M, V) bool) {
+		t.EachMeasured(yield)
+	}
+}
+
+// Iterate through the tree starting at the end, passing each value with the
+// measure of the values after it
+func (t FingerTree[MS, V, M]) EachMeasuredReverse(f func(suffix M, value V) bool) {
+	if t.reversed {
+		eachMeasured(measurerFor(t.f), t.f, measurerFor(t.f).Identity(), f)
+		return
+	}
+	eachMeasuredReverse(measurerFor(t.f), t.f, measurerFor(t.f).Identity(), f)
+}
+
+// Return the values from last to first with the measures of the values after
+// them, see [EachMeasuredReverse]
+func (t FingerTree[MS, V, M]) SeqMeasuredReverse() iter.Seq2[M, V] {
+	return func(yield func(M, V) bool) {
+		t.EachMeasuredReverse(yield)
+	}
+}
+
+func eachMeasured[V, M any](meas Measurer[V, M], t fingerTree[V, M], prefix M, f func(M, V) bool) bool {
+	switch t := force(t).(type) {
+	case *singleTree[V, M]:
+		return eachMeasuredElems(meas, []elem[V, M]{t.value}, prefix, f)
+	case *deepTree[V, M]:
+		if !eachMeasuredElems(meas, t.left.elems(), prefix, f) {
+			return false
+		}
+		prefix = meas.Sum(prefix, t.left.getMeasurement())
+		if !eachMeasured(meas, t.mid, prefix, f) {
+			return false
+		}
+		return eachMeasuredElems(meas, t.right.elems(), meas.Sum(prefix, t.mid.measurement()), f)
+	}
+	return true
+}
+
+// The caller knows the measure after the last item so it is not measured
+func eachMeasuredElems[V, M any](meas Measurer[V, M], items []elem[V, M], prefix M, f func(M, V) bool) bool {
+	for i, item := range items {
+		if item.node != nil {
+			if !eachMeasuredElems(meas, item.node.elems(), prefix, f) {
+				return false
+			}
+		} else if !f(prefix, item.value) {
+			return false
+		}
+		if i < len(items)-1 {
+			prefix = meas.Sum(prefix, item.measure(meas))
+		}
+	}
+	return true
+}
+
+func eachMeasuredReverse[V, M any](meas Measurer[V, M], t fingerTree[V, M], suffix M, f func(M, V) bool) bool {
+	switch t := force(t).(type) {
+	case *singleTree[V, M]:
+		return eachMeasuredElemsReverse(meas, []elem[V, M]{t.value}, suffix, f)
+	case *deepTree[V, M]:
+		if !eachMeasuredElemsReverse(meas, t.right.elems(), suffix, f) {
+			return false
+		}
+		suffix = meas.Sum(t.right.getMeasurement(), suffix)
+		if !eachMeasuredReverse(meas, t.mid, suffix, f) {
+			return false
+		}
+		return eachMeasuredElemsReverse(meas, t.left.elems(), meas.Sum(t.mid.measurement(), suffix), f)
+	}
+	return true
+}
+
+func eachMeasuredElemsReverse[V, M any](meas Measurer[V, M], items []elem[V, M], suffix M, f func(M, V) bool) bool {
+	for i := len(items) - 1; i >= 0; i-- {
+		item := items[i]
+		if item.node != nil {
+			if !eachMeasuredElemsReverse(meas, item.node.elems(), suffix, f) {
+				return false
+			}
+		} else if !f(suffix, item.value) {
+			return false
+		}
+		if i > 0 {
+			suffix = meas.Sum(item.measure(meas), suffix)
+		}
+	}
+	return true
+}
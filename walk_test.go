@@ -0,0 +1,122 @@
+package lazyfingertree
+
+import (
+	"slices"
+	"testing"
+)
+
+type countingWidth struct {
+	calls *int
+}
+
+func (w countingWidth) Identity() int { return 0 }
+func (w countingWidth) Measure(v int) int {
+	*w.calls++
+	return 1
+}
+func (w countingWidth) Sum(a int, b int) int { return a + b }
+
+func after(n int) Predicate[int] {
+	return func(m int) bool { return m > n }
+}
+
+func TestSeqRange(t *testing.T) {
+	for _, size := range []int{0, 1, 2, 5, 17, 100, 1000} {
+		tree := newTree[int]()
+		if size > 0 {
+			tree = lazyTree(size)
+		}
+		values := tree.ToSlice()
+		for _, tr := range []FingerTree[width[int, int], int, int]{tree, tree.Reverse()} {
+			if tr.IsReversed() {
+				values = reversed(values)
+			}
+			for from := 0; from <= size+1; from += max(1, size/7) {
+				for to := from; to <= size+1; to += max(1, size/5) {
+					lo, hi := min(from, size), min(to, size)
+					failIfNot(t, same(slices.Collect(tr.SeqRange(after(from), after(to))), values[lo:hi]))
+				}
+				failIfNot(t, same(slices.Collect(tr.SeqFrom(after(from))), values[min(from, size):]))
+				end := min(from+1, size)
+				failIfNot(t, same(slices.Collect(tr.SeqReverseFrom(after(from))), reversed(values[:end])))
+			}
+			failIfNot(t, same(slices.Collect(tr.SeqRange(nil, nil)), values))
+		}
+	}
+}
+
+func TestSeqRangeMeasures(t *testing.T) {
+	calls := 0
+	nums := make([]int, 100000)
+	tree := FromArray(countingWidth{&calls}, nums)
+	calls = 0
+	count := 0
+	for range tree.SeqRange(after(50000), after(50100)) {
+		count++
+	}
+	failIfNot(t, count == 100)
+	if calls > 200 {
+		t.Fatalf("measured %d values", calls)
+	}
+	for range tree.SeqFrom(after(50000)) {
+		break
+	}
+	// reversed trees walk in place with suffix measures
+	calls = 0
+	count = 0
+	for v := range tree.Reverse().SeqRange(after(50000), after(50100)) {
+		failIfNot(t, v == 0)
+		count++
+	}
+	failIfNot(t, count == 100)
+	for range tree.Reverse().SeqReverseFrom(after(50000)) {
+		count++
+		if count == 200 {
+			break
+		}
+	}
+	if calls > 400 {
+		t.Fatalf("measured %d values in a reversed tree", calls)
+	}
+}
+
+func TestSeqMeasured(t *testing.T) {
+	calls := 0
+	nums := make([]int, 10000)
+	for i := range nums {
+		nums[i] = i
+	}
+	tree := FromArray(countingWidth{&calls}, nums)
+	calls = 0
+	i := 0
+	for prefix, v := range tree.SeqMeasured() {
+		failIfNot(t, prefix == i && v == i)
+		i++
+	}
+	failIfNot(t, i == len(nums))
+	// every value but the last in each node or digit is measured
+	if calls < len(nums)/2 || calls > len(nums)*7/10 {
+		t.Fatalf("measured %d of %d values", calls, len(nums))
+	}
+	calls = 0
+	for suffix, v := range tree.SeqMeasuredReverse() {
+		i--
+		failIfNot(t, suffix == len(nums)-1-i && v == i)
+	}
+	failIfNot(t, i == 0)
+	if calls < len(nums)/2 || calls > len(nums)*7/10 {
+		t.Fatalf("measured %d of %d values in reverse", calls, len(nums))
+	}
+	for prefix, v := range tree.Reverse().SeqMeasured() {
+		failIfNot(t, prefix == len(nums)-1-v)
+		if prefix == 10 {
+			break
+		}
+	}
+	for suffix, v := range tree.Reverse().SeqMeasuredReverse() {
+		failIfNot(t, suffix == v)
+	}
+	for range newTree[int]().SeqMeasured() {
+		t.Fatal("empty tree has values")
+	}
+}